@@ -0,0 +1,28 @@
+// Command fakeapi serves the in-memory fake Spheron API, so the provider can
+// be planned and applied against it without touching the real API.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"terraform-provider-spherontest/internal/fakeapi"
+)
+
+func main() {
+	var addr string
+	var token string
+
+	flag.StringVar(&addr, "addr", "127.0.0.1:8080", "address to listen on")
+	flag.StringVar(&token, "token", "fake-token", "access token accepted by the fake API")
+	flag.Parse()
+
+	server := fakeapi.New(token)
+
+	log.Printf("Serving fake Spheron API on http://%s for organization %s", addr, server.OrganizationID())
+
+	if err := http.ListenAndServe(addr, server); err != nil {
+		log.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,78 @@
+# Lifecycle smoke test against the fake Spheron API, see "Testing against the
+# fake API" in the README.
+terraform {
+  required_providers {
+    spherontest = {
+      source = "ilij4/spherontest"
+    }
+  }
+}
+
+provider "spherontest" {
+  api_url = "http://127.0.0.1:8080"
+  token   = "fake-token"
+}
+
+variable "tag" {
+  type    = string
+  default = "latest"
+}
+
+variable "replicas" {
+  type    = number
+  default = 1
+}
+
+data "spherontest_organization" "current" {}
+
+resource "spherontest_instance" "test" {
+  image         = "crccheck/hello-world"
+  tag           = var.tag
+  cluster_name  = "fake-cluster"
+  name          = "hello"
+  region        = "us-east"
+  machine_image = "Ventus Nano"
+  replicas      = var.replicas
+  storage       = 10
+
+  ports = [
+    {
+      container_port = 8000
+      exposed_port   = 80
+    }
+  ]
+
+  env = {
+    LOG_LEVEL = "info"
+  }
+}
+
+resource "spherontest_domain" "test" {
+  name          = "test.com"
+  type          = "domain"
+  instance_id   = spherontest_instance.test.id
+  instance_port = spherontest_instance.test.ports[0].container_port
+}
+
+resource "spherontest_marketplace_instance" "test" {
+  name          = "Postgres"
+  machine_image = "Ventus Nano"
+  region        = "any"
+  replicas      = 1
+  storage       = 10
+
+  env = [
+    {
+      key   = "POSTGRES_PASSWORD"
+      value = "password"
+    },
+    {
+      key   = "POSTGRES_USER"
+      value = "admin"
+    },
+    {
+      key   = "POSTGRES_DB"
+      value = "db"
+    }
+  ]
+}
@@ -0,0 +1,338 @@
+// Package cassette records Spheron API traffic into YAML cassettes and
+// replays it, so client code can be exercised with real payloads offline.
+//
+// A Recorder is an http.RoundTripper and is plugged into the client with
+// client.WithTransport:
+//
+//	recorder, err := cassette.New("testdata/instance.yaml", cassette.ModeRecord, nil)
+//	api, err := client.NewSpheronApi(token, "", client.WithTransport(recorder))
+//	...
+//	err = recorder.Stop()
+//
+// Response bodies are recorded as far as the client read them before closing,
+// so event streams are captured up to the event the client waited for.
+// Credentials are scrubbed from headers, and secret envs and marketplace
+// variables from bodies, before anything is written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"terraform-provider-spherontest/internal/client/redact"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mode selects whether a Recorder records or replays traffic.
+type Mode int
+
+const (
+	// ModeRecord sends requests to the API and records the interactions.
+	ModeRecord Mode = iota
+	// ModeReplay answers requests from a recorded cassette.
+	ModeReplay
+)
+
+// redacted replaces scrubbed header values in cassettes.
+const redacted = redact.Value
+
+// eventDataPrefix starts the data lines of an event stream.
+const eventDataPrefix = "data: "
+
+// scrubbedHeaders are never written to a cassette as they hold credentials.
+var scrubbedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+type Cassette struct {
+	Interactions []*Interaction `yaml:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+
+	replayed bool
+}
+
+type Request struct {
+	Method  string              `yaml:"method"`
+	URL     string              `yaml:"url"`
+	Headers map[string][]string `yaml:"headers,omitempty"`
+	Body    string              `yaml:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int                 `yaml:"status_code"`
+	Headers    map[string][]string `yaml:"headers,omitempty"`
+	Body       string              `yaml:"body,omitempty"`
+}
+
+// Recorder records or replays HTTP interactions of a cassette file.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// New returns a Recorder for the cassette at path. In ModeRecord requests are
+// sent with transport, or http.DefaultTransport when nil. In ModeReplay the
+// cassette is loaded from path.
+func New(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	recorder := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: transport,
+	}
+
+	if mode == ModeReplay {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(content, &recorder.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %v", path, err)
+		}
+	}
+
+	return recorder, nil
+}
+
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	if r.mode == ModeReplay {
+		return r.replay(request)
+	}
+	return r.record(request)
+}
+
+// Stop writes the recorded interactions to the cassette file. It does
+// nothing in ModeReplay.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	content, err := yaml.Marshal(&r.cassette)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, content, 0o644)
+}
+
+func (r *Recorder) record(request *http.Request) (*http.Response, error) {
+	requestBody, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{
+		Request: Request{
+			Method:  request.Method,
+			URL:     request.URL.String(),
+			Headers: scrubHeaders(request.Header),
+			Body:    scrubBody(requestBody),
+		},
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Headers:    scrubHeaders(response.Header),
+		},
+	}
+
+	// The slot is taken right away so interactions keep the request order,
+	// the body is filled in once the client closes it.
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	response.Body = &recordingBody{
+		body: response.Body,
+		onClose: func(body string) {
+			r.mu.Lock()
+			interaction.Response.Body = scrubBody(body)
+			if _, ok := interaction.Response.Headers["Content-Length"]; ok {
+				interaction.Response.Headers["Content-Length"] = []string{strconv.Itoa(len(interaction.Response.Body))}
+			}
+			r.mu.Unlock()
+		},
+	}
+
+	return response, nil
+}
+
+// replay answers a request with the first interaction not replayed yet with
+// the same method, path and body. Bodies are compared once scrubbed, as the
+// cassette only holds scrubbed bodies, and JSON bodies are compared by value.
+// Query params are not matched, as they contain ids generated for each run,
+// like event stream session ids.
+func (r *Recorder) replay(request *http.Request) (*http.Response, error) {
+	requestBody, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	requestBody = scrubBody(requestBody)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.replayed || interaction.Request.Method != request.Method {
+			continue
+		}
+
+		if !samePath(interaction.Request.URL, request.URL.Path) || !sameBody(interaction.Request.Body, requestBody) {
+			continue
+		}
+
+		interaction.replayed = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header(interaction.Response.Headers),
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette %s has no interaction left for %s %s with body %s", r.path, request.Method, request.URL.Path, requestBody)
+}
+
+func samePath(recordedURL string, path string) bool {
+	recorded, err := url.Parse(recordedURL)
+	if err != nil {
+		return false
+	}
+	return recorded.Path == path
+}
+
+// sameBody reports whether a recorded body matches a scrubbed request body,
+// ignoring the formatting and key order of JSON bodies.
+func sameBody(recorded string, body string) bool {
+	if recorded == body {
+		return true
+	}
+
+	var recordedValue, value any
+	if json.Unmarshal([]byte(recorded), &recordedValue) != nil || json.Unmarshal([]byte(body), &value) != nil {
+		return false
+	}
+	return reflect.DeepEqual(recordedValue, value)
+}
+
+func readRequestBody(request *http.Request) (string, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return "", err
+	}
+	request.Body.Close()
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	return string(body), nil
+}
+
+func scrubHeaders(headers http.Header) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	scrubbed := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		scrubbed[name] = values
+	}
+
+	for _, name := range scrubbedHeaders {
+		if _, ok := scrubbed[name]; ok {
+			scrubbed[name] = []string{redacted}
+		}
+	}
+
+	return scrubbed
+}
+
+// scrubBody redacts secrets from a JSON body, or from the JSON data lines of
+// an event stream.
+func scrubBody(body string) string {
+	if body == "" || json.Valid([]byte(body)) {
+		return redact.JSONBody([]byte(body))
+	}
+
+	lines := strings.SplitAfter(body, "\n")
+	for i, line := range lines {
+		data, ok := strings.CutPrefix(line, eventDataPrefix)
+		if !ok {
+			continue
+		}
+
+		trimmed := strings.TrimRight(data, "\r\n")
+		if json.Valid([]byte(trimmed)) {
+			lines[i] = eventDataPrefix + redact.JSONBody([]byte(trimmed)) + data[len(trimmed):]
+		}
+	}
+
+	return strings.Join(lines, "")
+}
+
+// recordingBody keeps a copy of everything read from a response body and
+// hands it over when the body is closed.
+type recordingBody struct {
+	body    io.ReadCloser
+	buffer  bytes.Buffer
+	onClose func(body string)
+	closed  bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.buffer.Write(p[:n])
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		b.onClose(b.buffer.String())
+	}
+
+	err := b.body.Close()
+	if errors.Is(err, http.ErrBodyReadAfterClose) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScrubBody(t *testing.T) {
+	testCases := map[string]struct {
+		body     string
+		expected string
+	}{
+		"empty": {
+			body:     "",
+			expected: "",
+		},
+		"json": {
+			body:     `{"env":[{"isSecret":true,"value":"S=secret"}]}` + "\n",
+			expected: `{"env":[{"isSecret":true,"value":"S=[REDACTED]"}]}`,
+		},
+		"event-stream": {
+			body:     "event: message\ndata: {\"env\":[{\"isSecret\":true,\"value\":\"S=secret\"}]}\n\n",
+			expected: "event: message\ndata: {\"env\":[{\"isSecret\":true,\"value\":\"S=[REDACTED]\"}]}\n\n",
+		},
+		"event-stream-not-json": {
+			body:     "event: message\ndata: ping\r\n\r\n",
+			expected: "event: message\ndata: ping\r\n\r\n",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if actual := scrubBody(testCase.body); actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecordScrubsSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	recorder, err := New(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := &http.Client{Transport: recorder}
+	body := `{"environmentVariables":[{"label":"Password","value":"secret"}],"env":[{"isSecret":true,"value":"S=secret"}]}`
+	request, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/cluster-instance/create", strings.NewReader(body))
+	request.Header.Set("Authorization", "Bearer secret")
+
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The client gets the actual response, only the cassette is scrubbed.
+	received, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(received) != body {
+		t.Errorf("expected the response body to be passed through, got %s", received)
+	}
+
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("unexpected error writing cassette: %s", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error reading cassette: %s", err)
+	}
+	if strings.Contains(string(content), "secret") {
+		t.Errorf("expected secrets to be scrubbed, got:\n%s", content)
+	}
+}
+
+func TestReplayMatchesBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	content := `interactions:
+    - request:
+        method: POST
+        url: http://api.invalid/v1/cluster-instance/create
+        body: '{"env":[{"isSecret":true,"value":"S=[REDACTED]"}],"tag":"1.25"}'
+      response:
+        status_code: 200
+        body: first
+    - request:
+        method: POST
+        url: http://api.invalid/v1/cluster-instance/create
+        body: '{"tag":"1.26"}'
+      response:
+        status_code: 200
+        body: second
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unexpected error writing cassette: %s", err)
+	}
+
+	recorder, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	client := &http.Client{Transport: recorder}
+
+	replay := func(body string) (string, error) {
+		response, err := client.Post("http://api.invalid/v1/cluster-instance/create", "application/json", strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+
+		received, err := io.ReadAll(response.Body)
+		return string(received), err
+	}
+
+	// Bodies are matched by value once scrubbed, whatever the recorded order.
+	if received, err := replay(`{ "tag": "1.26" }`); err != nil || received != "second" {
+		t.Errorf("expected the second interaction, got %q, %v", received, err)
+	}
+	if _, err := replay(`{"tag":"1.25"}`); err == nil || !strings.Contains(err.Error(), "no interaction left") {
+		t.Errorf("expected a request with another body not to be replayed, got %v", err)
+	}
+
+	if received, err := replay(`{"tag":"1.25","env":[{"isSecret":true,"value":"S=secret"}]}`); err != nil || received != "first" {
+		t.Errorf("expected the first interaction, got %q, %v", received, err)
+	}
+}
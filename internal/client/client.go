@@ -12,7 +12,12 @@ import (
 	"strings"
 	"time"
 
+	"terraform-provider-spherontest/internal/tracing"
+
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 type SpheronApi struct {
@@ -20,20 +25,85 @@ type SpheronApi struct {
 	token         string
 
 	organizationId string
+	userAgent      string
+
+	// credentialsErr is returned by all requests when the client was created
+	// without usable credentials.
+	credentialsErr error
+
+	// httpClient sends API requests, streamClient subscribes to event
+	// streams and has no timeout as deployments can take a while.
+	httpClient   *http.Client
+	streamClient *http.Client
+}
+
+// Option configures optional settings of a SpheronApi.
+type Option func(*SpheronApi)
+
+// WithTransport sets the transport used for all requests, for example one
+// built by NewTransport or a recorder of API traffic. http.DefaultTransport is
+// used by default.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(api *SpheronApi) {
+		api.httpClient.Transport = transport
+		api.streamClient.Transport = transport
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with all requests.
+func WithUserAgent(userAgent string) Option {
+	return func(api *SpheronApi) {
+		api.userAgent = userAgent
+	}
+}
+
+// WithCredentialsError makes all requests fail with err without reaching the
+// API, for clients created before the access token is known.
+func WithCredentialsError(err error) Option {
+	return func(api *SpheronApi) {
+		api.credentialsErr = err
+	}
 }
 
-func NewSpheronApi(token string) (*SpheronApi, error) {
+// DefaultApiUrl is the Spheron API used when no API URL is configured.
+const DefaultApiUrl = "https://api-dev.spheron.network"
+
+func NewSpheronApi(token string, apiUrl string, options ...Option) (*SpheronApi, error) {
+	if apiUrl == "" {
+		apiUrl = DefaultApiUrl
+	}
+
 	api := &SpheronApi{
-		spheronApiUrl: "https://api-dev.spheron.network",
-		// spheronApiUrl: "http://localhost:8080",
-		token: token,
+		spheronApiUrl: strings.TrimSuffix(apiUrl, "/"),
+		token:         token,
+		httpClient:    &http.Client{Timeout: 600 * time.Second},
+		streamClient:  &http.Client{},
+	}
+
+	for _, option := range options {
+		option(api)
 	}
 
 	return api, nil
 }
 
-func (api *SpheronApi) sendApiRequest(method string, path string, payload interface{}, params map[string]interface{}) ([]byte, error) {
-	client := &http.Client{Timeout: 600 * time.Second}
+func (api *SpheronApi) sendApiRequest(ctx context.Context, method string, path string, payload interface{}, params map[string]interface{}) (_ []byte, err error) {
+	if api.credentialsErr != nil {
+		return nil, api.credentialsErr
+	}
+
+	ctx = api.logContext(ctx)
+
+	ctx, span := tracing.Start(ctx, "Spheron API "+method,
+		semconv.HTTPRequestMethodKey.String(method),
+		semconv.URLPath(path),
+	)
+	defer func() {
+		if err != nil {
+			tracing.RecordError(span, err)
+		}
+		span.End()
+	}()
 
 	var jsonPayload []byte
 	if payload != nil {
@@ -44,13 +114,15 @@ func (api *SpheronApi) sendApiRequest(method string, path string, payload interf
 		}
 	}
 
-	request, err := http.NewRequest(method, api.spheronApiUrl+path, bytes.NewBuffer(jsonPayload))
+	request, err := http.NewRequestWithContext(ctx, method, api.spheronApiUrl+path, bytes.NewBuffer(jsonPayload))
 	if err != nil {
 		return nil, err
 	}
 
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Authorization", "Bearer "+api.token)
+	api.setUserAgent(request)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(request.Header))
 
 	queryParams := request.URL.Query()
 	for key, value := range params {
@@ -58,40 +130,83 @@ func (api *SpheronApi) sendApiRequest(method string, path string, payload interf
 	}
 	request.URL.RawQuery = queryParams.Encode()
 
-	response, err := client.Do(request)
+	logApiRequest(ctx, method, path, jsonPayload)
+	start := time.Now()
+
+	response, err := api.httpClient.Do(request)
 	if err != nil {
+		tflog.SubsystemDebug(ctx, LogSubsystem, "API request failed", map[string]any{
+			"method":     method,
+			"path":       path,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"error":      err.Error(),
+		})
 		return nil, err
 	}
 	defer response.Body.Close()
 
-	if response.StatusCode >= 200 && response.StatusCode < 300 {
-		body, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			return nil, err
-		}
-		return body, nil
-	}
+	span.SetAttributes(
+		semconv.HTTPResponseStatusCode(response.StatusCode),
+		tracing.AttrRequestID.String(response.Header.Get(requestIDHeader)),
+	)
 
 	body, err := ioutil.ReadAll(response.Body)
+	logApiResponse(ctx, method, path, response.StatusCode, response.Header.Get(requestIDHeader), time.Since(start), body)
 	if err != nil {
 		return nil, err
 	}
 
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return body, nil
+	}
+
 	var errorResponse struct {
 		Message string `json:"message"`
 	}
 	if err := json.Unmarshal(body, &errorResponse); err != nil {
-		return nil, errors.New("API request failed with status: " + response.Status)
+		return nil, &statusError{statusCode: response.StatusCode, message: "API request failed with status: " + response.Status}
 	}
 
-	return nil, errors.New(errorResponse.Message)
+	return nil, &statusError{statusCode: response.StatusCode, message: errorResponse.Message}
+}
+
+// ErrNotFound matches, with errors.Is, the errors of requests the API answered
+// with 404 Not Found, either because the requested object doesn't exist or
+// because the API doesn't serve the endpoint.
+var ErrNotFound = errors.New("not found")
+
+// statusError is returned for API responses with an error status, with the
+// message of the API.
+type statusError struct {
+	statusCode int
+	message    string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func (e *statusError) Is(target error) bool {
+	return target == ErrNotFound && e.statusCode == http.StatusNotFound
+}
+
+// CredentialsError returns the error requests fail with when the client has no
+// usable credentials, and nil otherwise.
+func (api *SpheronApi) CredentialsError() error {
+	return api.credentialsErr
+}
+
+func (api *SpheronApi) setUserAgent(request *http.Request) {
+	if api.userAgent != "" {
+		request.Header.Set("User-Agent", api.userAgent)
+	}
 }
 
-func (api *SpheronApi) getTokenScope() (TokenScope, error) {
+func (api *SpheronApi) getTokenScope(ctx context.Context) (TokenScope, error) {
 	var tokenScope TokenScope
 	path := "/v1/api-keys/scope"
 
-	response, err := api.sendApiRequest(HttpMethodGet, path, nil, nil)
+	response, err := api.sendApiRequest(ctx, HttpMethodGet, path, nil, nil)
 	if err != nil {
 		return tokenScope, err
 	}
@@ -103,9 +218,9 @@ func (api *SpheronApi) getTokenScope() (TokenScope, error) {
 	return tokenScope, nil
 }
 
-func (api *SpheronApi) GetOrganizationId() (string, error) {
+func (api *SpheronApi) GetOrganizationId(ctx context.Context) (string, error) {
 	if api.organizationId == "" {
-		tokenScope, err := api.getTokenScope()
+		tokenScope, err := api.getTokenScope(ctx)
 		if err != nil {
 			return "", err
 		}
@@ -120,9 +235,9 @@ func (api *SpheronApi) GetOrganizationId() (string, error) {
 	return api.organizationId, nil
 }
 
-func (api *SpheronApi) getOrganizationById(id string) (Organization, error) {
+func (api *SpheronApi) getOrganizationById(ctx context.Context, id string) (Organization, error) {
 	var organization Organization
-	response, err := api.sendApiRequest(HttpMethodGet, fmt.Sprintf("/v1/organization/%s", id), nil, nil)
+	response, err := api.sendApiRequest(ctx, HttpMethodGet, fmt.Sprintf("/v1/organization/%s", id), nil, nil)
 
 	if err != nil {
 		return organization, err
@@ -135,13 +250,13 @@ func (api *SpheronApi) getOrganizationById(id string) (Organization, error) {
 	return organization, nil
 }
 
-func (api *SpheronApi) GetOrganization() (Organization, error) {
-	organizationId, err := api.GetOrganizationId()
+func (api *SpheronApi) GetOrganization(ctx context.Context) (Organization, error) {
+	organizationId, err := api.GetOrganizationId(ctx)
 	if err != nil {
 		return Organization{}, err
 	}
 
-	organization, err := api.getOrganizationById(organizationId)
+	organization, err := api.getOrganizationById(ctx, organizationId)
 	if err != nil {
 		return Organization{}, err
 	}
@@ -149,9 +264,9 @@ func (api *SpheronApi) GetOrganization() (Organization, error) {
 	return organization, nil
 }
 
-func (api *SpheronApi) CreateClusterInstance(clusterInstance CreateInstanceRequest) (InstanceResponse, error) {
+func (api *SpheronApi) CreateClusterInstance(ctx context.Context, clusterInstance CreateInstanceRequest) (InstanceResponse, error) {
 	var instanceResponse InstanceResponse
-	response, err := api.sendApiRequest(HttpMethodPost, "/v1/cluster-instance/create", clusterInstance, nil)
+	response, err := api.sendApiRequest(ctx, HttpMethodPost, "/v1/cluster-instance/create", clusterInstance, nil)
 	if err != nil {
 		return instanceResponse, err
 	}
@@ -163,10 +278,10 @@ func (api *SpheronApi) CreateClusterInstance(clusterInstance CreateInstanceReque
 	return instanceResponse, nil
 }
 
-func (api *SpheronApi) CloseClusterInstance(id string) (GenericResponse, error) {
+func (api *SpheronApi) CloseClusterInstance(ctx context.Context, id string) (GenericResponse, error) {
 	path := fmt.Sprintf("/v1/cluster-instance/%s/close", id)
 
-	responseBytes, err := api.sendApiRequest("POST", path, nil, nil)
+	responseBytes, err := api.sendApiRequest(ctx, "POST", path, nil, nil)
 	if err != nil {
 		return GenericResponse{}, err
 	}
@@ -180,10 +295,10 @@ func (api *SpheronApi) CloseClusterInstance(id string) (GenericResponse, error)
 	return response, nil
 }
 
-func (api *SpheronApi) UpdateClusterInstance(id string, clusterInstance UpdateInstanceRequest) (InstanceResponse, error) {
+func (api *SpheronApi) UpdateClusterInstance(ctx context.Context, id string, clusterInstance UpdateInstanceRequest) (InstanceResponse, error) {
 	path := fmt.Sprintf("/v1/cluster-instance/%s/update", id)
 
-	responseBytes, err := api.sendApiRequest("PATCH", path, clusterInstance, nil)
+	responseBytes, err := api.sendApiRequest(ctx, "PATCH", path, clusterInstance, nil)
 	if err != nil {
 		return InstanceResponse{}, err
 	}
@@ -197,10 +312,10 @@ func (api *SpheronApi) UpdateClusterInstance(id string, clusterInstance UpdateIn
 	return response, nil
 }
 
-func (api *SpheronApi) UpdateClusterInstanceHealthCheckInfo(id string, healthCheck HealthCheckUpdateReq) (GenericResponse, error) {
+func (api *SpheronApi) UpdateClusterInstanceHealthCheckInfo(ctx context.Context, id string, healthCheck HealthCheckUpdateReq) (GenericResponse, error) {
 	path := fmt.Sprintf("/v1/cluster-instance/%s/update/health-check", id)
 
-	responseBytes, err := api.sendApiRequest("PATCH", path, healthCheck, nil)
+	responseBytes, err := api.sendApiRequest(ctx, "PATCH", path, healthCheck, nil)
 	if err != nil {
 		return GenericResponse{}, err
 	}
@@ -214,10 +329,10 @@ func (api *SpheronApi) UpdateClusterInstanceHealthCheckInfo(id string, healthChe
 	return response, nil
 }
 
-func (api *SpheronApi) GetClusterInstance(id string) (Instance, error) {
+func (api *SpheronApi) GetClusterInstance(ctx context.Context, id string) (Instance, error) {
 	path := fmt.Sprintf("/v1/cluster-instance/%s", id)
 
-	responseBytes, err := api.sendApiRequest("GET", path, nil, nil)
+	responseBytes, err := api.sendApiRequest(ctx, "GET", path, nil, nil)
 	if err != nil {
 		return Instance{}, err
 	}
@@ -231,17 +346,33 @@ func (api *SpheronApi) GetClusterInstance(id string) (Instance, error) {
 	return response.Instance, nil
 }
 
-func (api *SpheronApi) WaitForDeployedEvent(ctx context.Context, topicID string) (string, error) {
+func (api *SpheronApi) WaitForDeployedEvent(ctx context.Context, topicID string) (_ string, err error) {
+	if api.credentialsErr != nil {
+		return "", api.credentialsErr
+	}
+
+	ctx = api.logContext(ctx)
+
+	ctx, span := tracing.Start(ctx, "Spheron API wait for deployment", tracing.AttrTopicID.String(topicID))
+	defer func() {
+		if err != nil {
+			tracing.RecordError(span, err)
+		}
+		span.End()
+	}()
+
 	url := fmt.Sprintf(api.spheronApiUrl+"/v1/subscribe?sessionId=%s", topicID)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+api.token)
+	api.setUserAgent(req)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := api.streamClient.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -256,7 +387,7 @@ func (api *SpheronApi) WaitForDeployedEvent(ctx context.Context, topicID string)
 
 		if strings.HasPrefix(line, "event: message") {
 			data, err := reader.ReadString('\n')
-			tflog.Info(ctx, fmt.Sprintf("%s", data))
+			logDeploymentEvent(ctx, topicID, data)
 
 			if err != nil {
 				return "", err
@@ -273,10 +404,10 @@ func (api *SpheronApi) WaitForDeployedEvent(ctx context.Context, topicID string)
 	}
 }
 
-func (api *SpheronApi) AddClusterInstanceDomain(instanceID string, domain DomainRequest) (Domain, error) {
+func (api *SpheronApi) AddClusterInstanceDomain(ctx context.Context, instanceID string, domain DomainRequest) (Domain, error) {
 	path := fmt.Sprintf("/v1/cluster-instance/%s/domains", instanceID)
 
-	responseBytes, err := api.sendApiRequest("POST", path, domain, nil)
+	responseBytes, err := api.sendApiRequest(ctx, "POST", path, domain, nil)
 	if err != nil {
 		return Domain{}, err
 	}
@@ -290,10 +421,10 @@ func (api *SpheronApi) AddClusterInstanceDomain(instanceID string, domain Domain
 	return response.Domain, nil
 }
 
-func (api *SpheronApi) UpdateClusterInstanceDomain(instanceID, domainID string, domain DomainRequest) (Domain, error) {
+func (api *SpheronApi) UpdateClusterInstanceDomain(ctx context.Context, instanceID, domainID string, domain DomainRequest) (Domain, error) {
 	path := fmt.Sprintf("/v1/cluster-instance/%s/domains/%s", instanceID, domainID)
 
-	responseBytes, err := api.sendApiRequest("PATCH", path, domain, nil)
+	responseBytes, err := api.sendApiRequest(ctx, "PATCH", path, domain, nil)
 	if err != nil {
 		return Domain{}, err
 	}
@@ -307,10 +438,10 @@ func (api *SpheronApi) UpdateClusterInstanceDomain(instanceID, domainID string,
 	return response.Domain, nil
 }
 
-func (api *SpheronApi) DeleteClusterInstanceDomain(instanceID, domainID string) error {
+func (api *SpheronApi) DeleteClusterInstanceDomain(ctx context.Context, instanceID, domainID string) error {
 	path := fmt.Sprintf("/v1/cluster-instance/%s/domains/%s", instanceID, domainID)
 
-	_, err := api.sendApiRequest("DELETE", path, nil, nil)
+	_, err := api.sendApiRequest(ctx, "DELETE", path, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -318,10 +449,10 @@ func (api *SpheronApi) DeleteClusterInstanceDomain(instanceID, domainID string)
 	return nil
 }
 
-func (api *SpheronApi) GetClusterInstanceOrder(id string) (InstanceOrder, error) {
+func (api *SpheronApi) GetClusterInstanceOrder(ctx context.Context, id string) (InstanceOrder, error) {
 	path := fmt.Sprintf("/v1/cluster-instance/order/%s", id)
 
-	responseBytes, err := api.sendApiRequest("GET", path, nil, nil)
+	responseBytes, err := api.sendApiRequest(ctx, "GET", path, nil, nil)
 	if err != nil {
 		return InstanceOrder{}, err
 	}
@@ -338,10 +469,10 @@ func (api *SpheronApi) GetClusterInstanceOrder(id string) (InstanceOrder, error)
 	return response.Order, nil
 }
 
-func (api *SpheronApi) CreateClusterInstanceFromTemplate(request CreateInstanceFromMarketplaceRequest) (InstanceResponse, error) {
+func (api *SpheronApi) CreateClusterInstanceFromTemplate(ctx context.Context, request CreateInstanceFromMarketplaceRequest) (InstanceResponse, error) {
 	path := "/v1/cluster-instance/template"
 
-	responseBytes, err := api.sendApiRequest("POST", path, request, nil)
+	responseBytes, err := api.sendApiRequest(ctx, "POST", path, request, nil)
 	if err != nil {
 		return InstanceResponse{}, err
 	}
@@ -355,10 +486,10 @@ func (api *SpheronApi) CreateClusterInstanceFromTemplate(request CreateInstanceF
 	return response, nil
 }
 
-func (api *SpheronApi) GetClusterTemplates() ([]MarketplaceApp, error) {
+func (api *SpheronApi) GetClusterTemplates(ctx context.Context) ([]MarketplaceApp, error) {
 	path := "/v1/cluster-templates"
 
-	responseBytes, err := api.sendApiRequest("GET", path, nil, nil)
+	responseBytes, err := api.sendApiRequest(ctx, "GET", path, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -374,33 +505,85 @@ func (api *SpheronApi) GetClusterTemplates() ([]MarketplaceApp, error) {
 	return response.ClusterTemplates, nil
 }
 
-func (api *SpheronApi) GetComputeMachines() ([]ComputeMachine, error) {
+func (api *SpheronApi) GetComputeMachines(ctx context.Context) ([]ComputeMachine, error) {
 	path := "/v1/compute-machine-image"
 
-	requestOptions := map[string]interface{}{
-		"skip":  "0",
-		"limit": "10",
-	}
+	return listAllPages(10, func(skip int, limit int) ([]ComputeMachine, error) {
+		requestOptions := map[string]interface{}{
+			"skip":  fmt.Sprint(skip),
+			"limit": fmt.Sprint(limit),
+		}
+
+		responseBytes, err := api.sendApiRequest(ctx, HttpMethodGet, path, nil, requestOptions)
+		if err != nil {
+			return nil, err
+		}
 
-	responseBytes, err := api.sendApiRequest("GET", path, nil, requestOptions)
+		var response struct {
+			AkashMachineImages []ComputeMachine `json:"akashMachineImages"`
+			TotalCount         int              `json:"totalCount"`
+		}
+		err = json.Unmarshal(responseBytes, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		return response.AkashMachineImages, nil
+	}, func(machine ComputeMachine) string { return machine.ID })
+}
+
+func (api *SpheronApi) GetComputeMachineRegions(ctx context.Context) ([]string, error) {
+	response, err := api.sendApiRequest(ctx, HttpMethodGet, "/v1/compute-machine-image/regions", nil, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var response struct {
-		AkashMachineImages []ComputeMachine `json:"akashMachineImages"`
-		TotalCount         int              `json:"totalCount"`
+	var responseWrapper struct {
+		Regions []string `json:"regions"`
 	}
-	err = json.Unmarshal(responseBytes, &response)
+	err = json.Unmarshal(response, &responseWrapper)
 	if err != nil {
 		return nil, err
 	}
+	return responseWrapper.Regions, nil
+}
+
+// GetComputeLimits returns the cpu and memory options and storage limits of
+// custom compute specs.
+func (api *SpheronApi) GetComputeLimits(ctx context.Context) (ComputeLimits, error) {
+	response, err := api.sendApiRequest(ctx, HttpMethodGet, "/v1/compute-machine-image/limits", nil, nil)
+	if err != nil {
+		return ComputeLimits{}, err
+	}
+
+	var responseWrapper struct {
+		Limits ComputeLimits `json:"limits"`
+	}
+	err = json.Unmarshal(response, &responseWrapper)
+	if err != nil {
+		return ComputeLimits{}, err
+	}
+	return responseWrapper.Limits, nil
+}
+
+func (api *SpheronApi) GetComputePricing(ctx context.Context) (ComputePricing, error) {
+	response, err := api.sendApiRequest(ctx, HttpMethodGet, "/v1/compute-machine-image/pricing", nil, nil)
+	if err != nil {
+		return ComputePricing{}, err
+	}
 
-	return response.AkashMachineImages, nil
+	var responseWrapper struct {
+		Pricing ComputePricing `json:"pricing"`
+	}
+	err = json.Unmarshal(response, &responseWrapper)
+	if err != nil {
+		return ComputePricing{}, err
+	}
+	return responseWrapper.Pricing, nil
 }
 
-func (api *SpheronApi) GetCluster(id string) (Cluster, error) {
-	response, err := api.sendApiRequest(HttpMethodGet, fmt.Sprintf("/v1/cluster/%s", id), nil, nil)
+func (api *SpheronApi) GetCluster(ctx context.Context, id string) (Cluster, error) {
+	response, err := api.sendApiRequest(ctx, HttpMethodGet, fmt.Sprintf("/v1/cluster/%s", id), nil, nil)
 	if err != nil {
 		return Cluster{}, err
 	}
@@ -415,8 +598,144 @@ func (api *SpheronApi) GetCluster(id string) (Cluster, error) {
 	return responseWrapper.Cluster, nil
 }
 
-func (api *SpheronApi) GetClusterInstanceDomains(id string) ([]Domain, error) {
-	response, err := api.sendApiRequest(HttpMethodGet, fmt.Sprintf("/v1/cluster-instance/%s/domains", id), nil, nil)
+func (api *SpheronApi) CreateCluster(ctx context.Context, request CreateClusterRequest) (Cluster, error) {
+	response, err := api.sendApiRequest(ctx, HttpMethodPost, "/v1/cluster", request, nil)
+	if err != nil {
+		return Cluster{}, err
+	}
+
+	var responseWrapper struct {
+		Cluster Cluster `json:"cluster"`
+	}
+	err = json.Unmarshal(response, &responseWrapper)
+	if err != nil {
+		return Cluster{}, err
+	}
+	return responseWrapper.Cluster, nil
+}
+
+func (api *SpheronApi) UpdateCluster(ctx context.Context, id string, request UpdateClusterRequest) (Cluster, error) {
+	response, err := api.sendApiRequest(ctx, HttpMethodPatch, fmt.Sprintf("/v1/cluster/%s", id), request, nil)
+	if err != nil {
+		return Cluster{}, err
+	}
+
+	var responseWrapper struct {
+		Cluster Cluster `json:"cluster"`
+	}
+	err = json.Unmarshal(response, &responseWrapper)
+	if err != nil {
+		return Cluster{}, err
+	}
+	return responseWrapper.Cluster, nil
+}
+
+func (api *SpheronApi) DeleteCluster(ctx context.Context, id string) error {
+	_, err := api.sendApiRequest(ctx, HttpMethodDelete, fmt.Sprintf("/v1/cluster/%s", id), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (api *SpheronApi) GetOrganizationClusters(ctx context.Context, organizationID string) ([]Cluster, error) {
+	response, err := api.sendApiRequest(ctx, HttpMethodGet, fmt.Sprintf("/v1/organization/%s/clusters", organizationID), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseWrapper struct {
+		Clusters []Cluster `json:"clusters"`
+	}
+	err = json.Unmarshal(response, &responseWrapper)
+	if err != nil {
+		return nil, err
+	}
+	return responseWrapper.Clusters, nil
+}
+
+func (api *SpheronApi) GetOrganizationClusterInstances(ctx context.Context, organizationID string) ([]Instance, error) {
+	path := fmt.Sprintf("/v1/organization/%s/cluster-instances", organizationID)
+
+	return listAllPages(50, func(skip int, limit int) ([]Instance, error) {
+		requestOptions := map[string]interface{}{
+			"skip":  fmt.Sprint(skip),
+			"limit": fmt.Sprint(limit),
+		}
+
+		responseBytes, err := api.sendApiRequest(ctx, HttpMethodGet, path, nil, requestOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		var response struct {
+			ClusterInstances []Instance `json:"clusterInstances"`
+		}
+		err = json.Unmarshal(responseBytes, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		return response.ClusterInstances, nil
+	}, func(instance Instance) string {
+		return instance.ID
+	})
+}
+
+// maxListPages bounds the number of pages listAllPages requests, in case the
+// API keeps returning full pages.
+const maxListPages = 1000
+
+// listAllPages fetches pages of limit items until a page is shorter than the
+// limit. It also stops when a page holds no items not seen before, as happens
+// when the API ignores the skip and limit params.
+func listAllPages[T any](limit int, fetch func(skip int, limit int) ([]T, error), id func(T) string) ([]T, error) {
+	items := []T{}
+	seen := map[string]bool{}
+
+	for page := 0; page < maxListPages; page++ {
+		pageItems, err := fetch(page*limit, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, item := range pageItems {
+			if seen[id(item)] {
+				continue
+			}
+			seen[id(item)] = true
+			items = append(items, item)
+			added++
+		}
+
+		if len(pageItems) < limit || added == 0 {
+			return items, nil
+		}
+	}
+
+	return nil, fmt.Errorf("listing stopped after %d pages, the API kept returning new items", maxListPages)
+}
+
+func (api *SpheronApi) GetClusterInstances(ctx context.Context, clusterID string) ([]Instance, error) {
+	response, err := api.sendApiRequest(ctx, HttpMethodGet, fmt.Sprintf("/v1/cluster/%s/instances", clusterID), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseWrapper struct {
+		Instances []Instance `json:"instances"`
+	}
+	err = json.Unmarshal(response, &responseWrapper)
+	if err != nil {
+		return nil, err
+	}
+	return responseWrapper.Instances, nil
+}
+
+func (api *SpheronApi) GetClusterInstanceDomains(ctx context.Context, id string) ([]Domain, error) {
+	response, err := api.sendApiRequest(ctx, HttpMethodGet, fmt.Sprintf("/v1/cluster-instance/%s/domains", id), nil, nil)
 	if err != nil {
 		return []Domain{}, err
 	}
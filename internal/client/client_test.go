@@ -0,0 +1,441 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/client/cassette"
+	"terraform-provider-spherontest/internal/fakeapi"
+)
+
+// updateCassettes records the cassettes in testdata against the Spheron API
+// set by SPHERON_TOKEN and SPHERON_API_URL instead of replaying them.
+var updateCassettes = flag.Bool("update-cassettes", false, "record the cassettes in testdata against the Spheron API set by SPHERON_TOKEN and SPHERON_API_URL")
+
+const (
+	// cassetteToken authenticates replayed requests, the cassettes only hold
+	// redacted credentials.
+	cassetteToken  = "cassette-token"
+	cassetteSecret = "cassette-secret-value"
+)
+
+// newInstancesServer serves pages of the organization instances list built by
+// page from the requested skip and limit, and counts the requests.
+func newInstancesServer(t *testing.T, page func(skip int, limit int, request int) []client.Instance) (*httptest.Server, *int) {
+	t.Helper()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"clusterInstances": page(skip, limit, requests),
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func instancesRange(from int, to int) []client.Instance {
+	instances := []client.Instance{}
+	for i := from; i < to; i++ {
+		instances = append(instances, client.Instance{ID: fmt.Sprintf("instance-%d", i)})
+	}
+	return instances
+}
+
+func TestGetOrganizationClusterInstancesPagination(t *testing.T) {
+	testCases := map[string]struct {
+		page          func(skip int, limit int, request int) []client.Instance
+		expectedCount int
+		maxRequests   int
+		expectError   bool
+	}{
+		"paginated": {
+			page: func(skip int, limit int, _ int) []client.Instance {
+				return instancesRange(skip, min(skip+limit, 120))
+			},
+			expectedCount: 120,
+			maxRequests:   3,
+		},
+		"exact-pages": {
+			page: func(skip int, limit int, _ int) []client.Instance {
+				return instancesRange(skip, min(skip+limit, 100))
+			},
+			expectedCount: 100,
+			maxRequests:   3,
+		},
+		"empty": {
+			page: func(int, int, int) []client.Instance {
+				return nil
+			},
+			expectedCount: 0,
+			maxRequests:   1,
+		},
+		"ignores-skip-and-limit": {
+			page: func(int, int, int) []client.Instance {
+				return instancesRange(0, 80)
+			},
+			expectedCount: 80,
+			maxRequests:   2,
+		},
+		"endless-new-pages": {
+			page: func(_ int, limit int, request int) []client.Instance {
+				return instancesRange(request*limit, (request+1)*limit)
+			},
+			expectError: true,
+			maxRequests: 1000,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server, requests := newInstancesServer(t, testCase.page)
+
+			api, err := client.NewSpheronApi("token", server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error creating client: %s", err)
+			}
+
+			instances, err := api.GetOrganizationClusterInstances(context.Background(), "organization")
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got %d instances", len(instances))
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if len(instances) != testCase.expectedCount {
+					t.Errorf("expected %d instances, got %d", testCase.expectedCount, len(instances))
+				}
+			}
+
+			if *requests > testCase.maxRequests {
+				t.Errorf("expected at most %d requests, got %d", testCase.maxRequests, *requests)
+			}
+		})
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	testCases := map[string]struct {
+		status           int
+		body             string
+		expectedMessage  string
+		expectedNotFound bool
+	}{
+		"not-found": {
+			status:           http.StatusNotFound,
+			body:             `{"message":"Cluster not found"}`,
+			expectedMessage:  "Cluster not found",
+			expectedNotFound: true,
+		},
+		"not-found-endpoint": {
+			status:           http.StatusNotFound,
+			body:             "404 page not found",
+			expectedMessage:  "API request failed with status: 404 Not Found",
+			expectedNotFound: true,
+		},
+		"bad-request": {
+			status:          http.StatusBadRequest,
+			body:            `{"message":"Invalid cluster name"}`,
+			expectedMessage: "Invalid cluster name",
+		},
+		"server-error": {
+			status:          http.StatusInternalServerError,
+			body:            "internal error",
+			expectedMessage: "API request failed with status: 500 Internal Server Error",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testCase.status)
+				_, _ = w.Write([]byte(testCase.body))
+			}))
+			t.Cleanup(server.Close)
+
+			api, err := client.NewSpheronApi("token", server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error creating client: %s", err)
+			}
+
+			_, err = api.GetCluster(context.Background(), "cluster")
+			if err == nil {
+				t.Fatal("expected error, got none")
+			}
+			if err.Error() != testCase.expectedMessage {
+				t.Errorf("expected error %q, got %q", testCase.expectedMessage, err.Error())
+			}
+			if notFound := errors.Is(err, client.ErrNotFound); notFound != testCase.expectedNotFound {
+				t.Errorf("expected errors.Is(err, ErrNotFound) to be %t, got %t", testCase.expectedNotFound, notFound)
+			}
+		})
+	}
+}
+
+func TestGetComputeMachinesPagination(t *testing.T) {
+	api := fakeapi.New("token")
+	for i := 0; i < 12; i++ {
+		api.AddComputeMachine(client.ComputeMachine{
+			Name:   fmt.Sprintf("Machine %d", i),
+			Cpu:    1,
+			Memory: "2Gi",
+		}, 0.1)
+	}
+
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	spheronApi, err := client.NewSpheronApi("token", server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	machines, err := spheronApi.GetComputeMachines(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The fake API is seeded with 3 machines.
+	if len(machines) != 15 {
+		t.Errorf("expected 15 machines, got %d", len(machines))
+	}
+}
+
+// newCassetteClient returns a client replaying the cassette testdata/name.yaml,
+// or recording it against the Spheron API with -update-cassettes. Recording
+// deploys real instances on the organization of SPHERON_TOKEN. Tests are
+// skipped when their cassette hasn't been recorded.
+func newCassetteClient(t *testing.T, name string) *client.SpheronApi {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".yaml")
+	token := cassetteToken
+	apiUrl := "http://cassette.invalid"
+	mode := cassette.ModeReplay
+
+	if *updateCassettes {
+		token = os.Getenv("SPHERON_TOKEN")
+		if token == "" {
+			t.Fatal("SPHERON_TOKEN must be set to record cassettes against the Spheron API")
+		}
+
+		apiUrl = os.Getenv("SPHERON_API_URL")
+		mode = cassette.ModeRecord
+	} else if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		t.Skipf("cassette %s not recorded, run with -update-cassettes and SPHERON_TOKEN set to record it", path)
+	}
+
+	recorder, err := cassette.New(path, mode, nil)
+	if err != nil {
+		t.Fatalf("unexpected error loading cassette: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := recorder.Stop(); err != nil {
+			t.Errorf("unexpected error writing cassette: %s", err)
+		}
+	})
+
+	api, err := client.NewSpheronApi(token, apiUrl, client.WithTransport(recorder))
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return api
+}
+
+func TestCassetteInstanceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	api := newCassetteClient(t, "instance")
+
+	organization, err := api.GetOrganization(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error getting organization: %s", err)
+	}
+
+	created, err := api.CreateClusterInstance(ctx, client.CreateInstanceRequest{
+		OrganizationID: organization.ID,
+		UniqueTopicID:  "create-topic",
+		InstanceName:   "web",
+		ClusterName:    "web",
+		Configuration: client.InstanceConfiguration{
+			Image:                 "nginx",
+			Tag:                   "1.25",
+			InstanceCount:         1,
+			Ports:                 []client.Port{{ContainerPort: 80}},
+			Env:                   []client.Env{{Value: "A=1"}, {Value: "S=" + cassetteSecret, IsSecret: true}},
+			Region:                "us-east",
+			AkashMachineImageName: "Ventus Nano",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance: %s", err)
+	}
+
+	if _, err := api.WaitForDeployedEvent(ctx, "create-topic"); err != nil {
+		t.Fatalf("unexpected error waiting for deployment: %s", err)
+	}
+
+	instance, err := api.GetClusterInstance(ctx, created.ClusterInstanceID)
+	if err != nil {
+		t.Fatalf("unexpected error getting instance: %s", err)
+	}
+	if instance.State != "Active" || instance.ActiveOrder != created.ClusterInstanceOrderID {
+		t.Errorf("unexpected instance: %+v", instance)
+	}
+
+	order, err := api.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
+	if err != nil {
+		t.Fatalf("unexpected error getting order: %s", err)
+	}
+	if !slices.Contains(order.ClusterInstanceConfiguration.Env, client.Env{Value: "A=1"}) {
+		t.Errorf("expected env A=1 to be deployed, got %+v", order.ClusterInstanceConfiguration.Env)
+	}
+
+	_, err = api.UpdateClusterInstance(ctx, instance.ID, client.UpdateInstanceRequest{
+		OrganizationID: organization.ID,
+		UniqueTopicID:  "update-topic",
+		Tag:            "1.26",
+		Env:            order.ClusterInstanceConfiguration.Env,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error updating instance: %s", err)
+	}
+
+	if _, err := api.WaitForDeployedEvent(ctx, "update-topic"); err != nil {
+		t.Fatalf("unexpected error waiting for deployment: %s", err)
+	}
+
+	updated, err := api.GetClusterInstance(ctx, instance.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting instance: %s", err)
+	}
+	if len(updated.Orders) != 2 {
+		t.Errorf("expected the update to create a new order, got %d orders", len(updated.Orders))
+	}
+
+	if _, err := api.CloseClusterInstance(ctx, instance.ID); err != nil {
+		t.Fatalf("unexpected error closing instance: %s", err)
+	}
+
+	closed, err := api.GetClusterInstance(ctx, instance.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting instance: %s", err)
+	}
+	if closed.State != "Closed" {
+		t.Errorf("expected closed instance, got state %s", closed.State)
+	}
+}
+
+func TestCassetteMarketplaceInstance(t *testing.T) {
+	ctx := context.Background()
+	api := newCassetteClient(t, "marketplace")
+
+	organization, err := api.GetOrganization(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error getting organization: %s", err)
+	}
+
+	templates, err := api.GetClusterTemplates(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error getting templates: %s", err)
+	}
+
+	index := slices.IndexFunc(templates, func(template client.MarketplaceApp) bool { return template.Name == "Postgres" })
+	if index < 0 {
+		t.Fatalf("Postgres template not found in %+v", templates)
+	}
+
+	machines, err := api.GetComputeMachines(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error getting machines: %s", err)
+	}
+
+	machine := slices.IndexFunc(machines, func(machine client.ComputeMachine) bool { return machine.Name == "Ventus Nano" })
+	if machine < 0 {
+		t.Fatalf("Ventus Nano not found in %+v", machines)
+	}
+
+	created, err := api.CreateClusterInstanceFromTemplate(ctx, client.CreateInstanceFromMarketplaceRequest{
+		TemplateID: templates[index].ID,
+		EnvironmentVariables: []client.MarketplaceDeploymentVariable{
+			{Label: "Password", Value: cassetteSecret},
+			{Label: "User", Value: "app"},
+			{Label: "Database", Value: "app"},
+		},
+		OrganizationID: organization.ID,
+		AkashImageID:   machines[machine].ID,
+		UniqueTopicID:  "create-topic",
+		Region:         "us-east",
+		InstanceCount:  1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance: %s", err)
+	}
+
+	if _, err := api.WaitForDeployedEvent(ctx, "create-topic"); err != nil {
+		t.Fatalf("unexpected error waiting for deployment: %s", err)
+	}
+
+	order, err := api.GetClusterInstanceOrder(ctx, created.ClusterInstanceOrderID)
+	if err != nil {
+		t.Fatalf("unexpected error getting order: %s", err)
+	}
+	if !slices.Contains(order.ClusterInstanceConfiguration.Env, client.Env{Value: "POSTGRES_DB=app"}) {
+		t.Errorf("expected env POSTGRES_DB=app to be deployed, got %+v", order.ClusterInstanceConfiguration.Env)
+	}
+}
+
+func TestCassettesScrubbed(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error listing cassettes: %s", err)
+	}
+	if len(paths) == 0 {
+		t.Skip("no cassettes recorded in testdata")
+	}
+
+	secrets := []string{cassetteToken, cassetteSecret}
+	if token := os.Getenv("SPHERON_TOKEN"); token != "" {
+		secrets = append(secrets, token)
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error reading cassette: %s", err)
+			}
+
+			for _, secret := range secrets {
+				if strings.Contains(string(content), secret) {
+					t.Errorf("cassette contains %q", secret)
+				}
+			}
+			if !strings.Contains(string(content), "[REDACTED]") {
+				t.Error("expected cassette to contain redacted values")
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"terraform-provider-spherontest/internal/client/redact"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// LogSubsystem is the tflog subsystem API requests are logged to. Its level
+// can be set separately with the TF_LOG_PROVIDER_SPHERON_API env variable.
+const LogSubsystem = "spheron_api"
+
+// requestIDHeader is the response header holding the id the API assigned to
+// a request.
+const requestIDHeader = "X-Request-Id"
+
+// logContext returns ctx with the API logging subsystem, masking the access
+// token anywhere it would be logged.
+func (api *SpheronApi) logContext(ctx context.Context) context.Context {
+	ctx = tflog.NewSubsystem(ctx, LogSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_SPHERON_API"))
+
+	if api.token != "" {
+		ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, LogSubsystem, api.token)
+		ctx = tflog.SubsystemMaskMessageStrings(ctx, LogSubsystem, api.token)
+	}
+
+	return ctx
+}
+
+func logApiRequest(ctx context.Context, method string, path string, body []byte) {
+	tflog.SubsystemTrace(ctx, LogSubsystem, "Sending API request", map[string]any{
+		"method": method,
+		"path":   path,
+		"body":   redactLogBody(body),
+	})
+}
+
+func logApiResponse(ctx context.Context, method string, path string, status int, requestID string, latency time.Duration, body []byte) {
+	fields := map[string]any{
+		"method":     method,
+		"path":       path,
+		"status":     status,
+		"latency_ms": latency.Milliseconds(),
+		"request_id": requestID,
+	}
+
+	tflog.SubsystemDebug(ctx, LogSubsystem, "API request completed", fields)
+
+	fields["body"] = redactLogBody(body)
+	tflog.SubsystemTrace(ctx, LogSubsystem, "API response body", fields)
+}
+
+// redactLogBody returns a JSON body for logging, with the values of secret
+// envs and marketplace variables replaced. Bodies which aren't JSON are
+// logged as is.
+func redactLogBody(body []byte) string {
+	return redact.JSONBody(body)
+}
+
+// logDeploymentEvent logs an event received while waiting for a deployment.
+func logDeploymentEvent(ctx context.Context, topicID string, data string) {
+	tflog.SubsystemTrace(ctx, LogSubsystem, "Received deployment event", map[string]any{
+		"topic_id": topicID,
+		"data":     redactLogBody([]byte(strings.TrimSpace(strings.TrimPrefix(data, "data:")))),
+	})
+}
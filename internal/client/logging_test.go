@@ -0,0 +1,147 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/fakeapi"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+const logToken = "log-token"
+
+// logEntries returns the entries logged by the API logging subsystem.
+func logEntries(t *testing.T, output *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	entries, err := tflogtest.MultilineJSONDecode(output)
+	if err != nil {
+		t.Fatalf("unexpected error decoding logs: %s", err)
+	}
+
+	subsystemEntries := []map[string]any{}
+	for _, entry := range entries {
+		if entry["@module"] == "provider."+client.LogSubsystem {
+			subsystemEntries = append(subsystemEntries, entry)
+		}
+	}
+	return subsystemEntries
+}
+
+func TestLoggingRedactsSecrets(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	api := fakeapi.New(logToken)
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	spheronApi, err := client.NewSpheronApi(logToken, server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	templates, err := spheronApi.GetClusterTemplates(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error getting templates: %s", err)
+	}
+
+	index := slices.IndexFunc(templates, func(template client.MarketplaceApp) bool { return template.Name == "Postgres" })
+	if index < 0 {
+		t.Fatalf("Postgres template not found in %+v", templates)
+	}
+
+	machines, err := spheronApi.GetComputeMachines(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error getting machines: %s", err)
+	}
+
+	machine := slices.IndexFunc(machines, func(machine client.ComputeMachine) bool { return machine.Name == "Ventus Nano" })
+	if machine < 0 {
+		t.Fatalf("Ventus Nano not found in %+v", machines)
+	}
+
+	_, err = spheronApi.CreateClusterInstanceFromTemplate(ctx, client.CreateInstanceFromMarketplaceRequest{
+		TemplateID: templates[index].ID,
+		EnvironmentVariables: []client.MarketplaceDeploymentVariable{
+			{Label: "Password", Value: "marketplace-secret"},
+			{Label: "User", Value: "app"},
+			{Label: "Database", Value: "app"},
+		},
+		OrganizationID: api.OrganizationID(),
+		AkashImageID:   machines[machine].ID,
+		UniqueTopicID:  "marketplace-topic",
+		Region:         "us-east",
+		InstanceCount:  1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating marketplace instance: %s", err)
+	}
+
+	_, err = spheronApi.CreateClusterInstance(ctx, client.CreateInstanceRequest{
+		OrganizationID: api.OrganizationID(),
+		UniqueTopicID:  "instance-topic",
+		InstanceName:   "web",
+		ClusterName:    "web",
+		Configuration: client.InstanceConfiguration{
+			Image:                 "nginx",
+			Tag:                   "1.25",
+			InstanceCount:         1,
+			Ports:                 []client.Port{{ContainerPort: 80}},
+			Env:                   []client.Env{{Value: "S=env-secret", IsSecret: true}},
+			Region:                "us-east",
+			AkashMachineImageName: "Ventus Nano",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance: %s", err)
+	}
+
+	if _, err := spheronApi.WaitForDeployedEvent(ctx, "instance-topic"); err != nil {
+		t.Fatalf("unexpected error waiting for deployment: %s", err)
+	}
+
+	logs := output.String()
+	for _, secret := range []string{logToken, "marketplace-secret", "env-secret"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("expected %q to be redacted from logs", secret)
+		}
+	}
+
+	entries := logEntries(t, &output)
+
+	var requestBodies, events int
+	for _, entry := range entries {
+		switch entry["@message"] {
+		case "Sending API request":
+			if strings.Contains(entry["body"].(string), "[REDACTED]") {
+				requestBodies++
+			}
+		case "Received deployment event":
+			events++
+			if entry["@level"] != "trace" {
+				t.Errorf("expected deployment events to be logged at trace level, got %s", entry["@level"])
+			}
+			if entry["topic_id"] != "instance-topic" || !strings.Contains(entry["data"].(string), `"deploymentStatus":"DEPLOYED"`) {
+				t.Errorf("unexpected deployment event entry: %v", entry)
+			}
+		}
+
+		if entry["@level"] == "info" {
+			t.Errorf("expected API logs below info level, got %v", entry)
+		}
+	}
+
+	if requestBodies != 2 {
+		t.Errorf("expected 2 request bodies with redacted values, got %d", requestBodies)
+	}
+	if events != 1 {
+		t.Errorf("expected 1 deployment event to be logged, got %d", events)
+	}
+}
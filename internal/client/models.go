@@ -39,6 +39,7 @@ type CreateInstanceRequest struct {
 	ClusterURL      string                `json:"clusterUrl"`
 	ClusterProvider string                `json:"clusterProvider"`
 	ClusterName     string                `json:"clusterName"`
+	ClusterID       string                `json:"clusterId,omitempty"`
 	HealthCheckURL  string                `json:"healthCheckUrl"`
 	HealthCheckPort string                `json:"healthCheckPort"`
 }
@@ -201,6 +202,7 @@ type ClusterInstanceConfiguration struct {
 	Region             string           `json:"region"`
 	AgreedMachineImage MachineImageType `json:"agreedMachineImage"`
 	InstanceCount      int              `json:"instanceCount"`
+	TemplateID         string           `json:"templateId,omitempty"`
 }
 
 type MarketplaceApp struct {
@@ -229,6 +231,7 @@ type CreateInstanceFromMarketplaceRequest struct {
 	Region               string                          `json:"region"`
 	CustomInstanceSpecs  CustomInstanceSpecs             `json:"customInstanceSpecs"`
 	InstanceCount        int                             `json:"instanceCount"`
+	InstanceName         string                          `json:"instanceName,omitempty"`
 }
 
 type MarketplaceDeploymentVariable struct {
@@ -237,8 +240,27 @@ type MarketplaceDeploymentVariable struct {
 }
 
 type ComputeMachine struct {
-	ID   string `json:"_id"`
-	Name string `json:"name"`
+	ID     string  `json:"_id"`
+	Name   string  `json:"name"`
+	Cpu    float32 `json:"cpu"`
+	Memory string  `json:"memory"`
+}
+
+type ComputeLimits struct {
+	Cpu                  []string `json:"cpu"`
+	Memory               []string `json:"memory"`
+	MaxStorage           int64    `json:"maxStorage"`
+	MaxPersistentStorage int64    `json:"maxPersistentStorage"`
+}
+
+// ComputePricing holds hourly prices in USD. Per unit prices are per CPU or
+// per GB, persistent storage prices are keyed by storage class.
+type ComputePricing struct {
+	MachineImages            map[string]float64 `json:"machineImages"`
+	CpuPerHour               float64            `json:"cpuPerHour"`
+	MemoryPerHour            float64            `json:"memoryPerHour"`
+	StoragePerHour           float64            `json:"storagePerHour"`
+	PersistentStoragePerHour map[string]float64 `json:"persistentStoragePerHour"`
 }
 
 type Cluster struct {
@@ -246,3 +268,12 @@ type Cluster struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
 }
+
+type CreateClusterRequest struct {
+	OrganizationID string `json:"organizationId"`
+	Name           string `json:"name"`
+}
+
+type UpdateClusterRequest struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,84 @@
+// Package redact removes secrets from Spheron API request and response
+// bodies before they are logged or recorded into cassettes.
+package redact
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Value replaces redacted secrets.
+const Value = "[REDACTED]"
+
+// credentialKeyPattern matches env names which usually hold credentials, so
+// their values are redacted even when the env isn't marked secret, like the
+// envs marketplace apps are deployed with.
+var credentialKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|api_?key|private_?key)`)
+
+// JSONBody returns a JSON body with its secrets redacted. Bodies which aren't
+// JSON are returned as is.
+func JSONBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return string(body)
+	}
+
+	redacted, err := json.Marshal(Secrets(decoded))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(redacted)
+}
+
+// Secrets walks a decoded JSON value and redacts, in place, the values of
+// envs marked with isSecret or named like a credential, keeping the env key
+// so output stays useful, and the values of marketplace deployment
+// variables.
+func Secrets(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		if _, ok := typed["isSecret"]; ok {
+			redactEnv(typed)
+		}
+
+		if variables, ok := typed["environmentVariables"].([]any); ok {
+			for _, variable := range variables {
+				if variable, ok := variable.(map[string]any); ok {
+					if _, ok := variable["value"].(string); ok {
+						variable["value"] = Value
+					}
+				}
+			}
+		}
+
+		for key, item := range typed {
+			typed[key] = Secrets(item)
+		}
+	case []any:
+		for i, item := range typed {
+			typed[i] = Secrets(item)
+		}
+	}
+
+	return value
+}
+
+// redactEnv redacts the value of a KEY=value env when it's secret.
+func redactEnv(env map[string]any) {
+	value, ok := env["value"].(string)
+	if !ok {
+		return
+	}
+
+	key, _, _ := strings.Cut(value, "=")
+	isSecret, _ := env["isSecret"].(bool)
+	if isSecret || credentialKeyPattern.MatchString(key) {
+		env["value"] = key + "=" + Value
+	}
+}
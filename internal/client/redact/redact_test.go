@@ -0,0 +1,51 @@
+package redact_test
+
+import (
+	"testing"
+
+	"terraform-provider-spherontest/internal/client/redact"
+)
+
+func TestJSONBody(t *testing.T) {
+	testCases := map[string]struct {
+		body     string
+		expected string
+	}{
+		"empty": {
+			body:     "",
+			expected: "",
+		},
+		"not-json": {
+			body:     "event: message",
+			expected: "event: message",
+		},
+		"secret-env": {
+			body:     `{"env":[{"isSecret":true,"value":"TOKEN=abc=def"},{"isSecret":false,"value":"A=1"}]}`,
+			expected: `{"env":[{"isSecret":true,"value":"TOKEN=[REDACTED]"},{"isSecret":false,"value":"A=1"}]}`,
+		},
+		"credential-env-not-secret": {
+			body:     `{"env":[{"isSecret":false,"value":"POSTGRES_PASSWORD=hunter2"},{"isSecret":false,"value":"db_api_key=k"}]}`,
+			expected: `{"env":[{"isSecret":false,"value":"POSTGRES_PASSWORD=[REDACTED]"},{"isSecret":false,"value":"db_api_key=[REDACTED]"}]}`,
+		},
+		"nested-order": {
+			body:     `{"order":{"clusterInstanceConfiguration":{"env":[{"isSecret":true,"value":"S"}]}}}`,
+			expected: `{"order":{"clusterInstanceConfiguration":{"env":[{"isSecret":true,"value":"S=[REDACTED]"}]}}}`,
+		},
+		"marketplace-variables": {
+			body:     `{"environmentVariables":[{"label":"Password","value":"hunter2"},{"label":"Database","value":"app"}],"templateId":"t"}`,
+			expected: `{"environmentVariables":[{"label":"Password","value":"[REDACTED]"},{"label":"Database","value":"[REDACTED]"}],"templateId":"t"}`,
+		},
+		"no-secrets": {
+			body:     `{"name":"web","variables":[{"name":"POSTGRES_PASSWORD","defaultValue":""}]}`,
+			expected: `{"name":"web","variables":[{"defaultValue":"","name":"POSTGRES_PASSWORD"}]}`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if actual := redact.JSONBody([]byte(testCase.body)); actual != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, actual)
+			}
+		})
+	}
+}
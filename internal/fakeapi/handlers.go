@@ -0,0 +1,693 @@
+package fakeapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"terraform-provider-spherontest/internal/client"
+)
+
+const (
+	instanceStateActive = "Active"
+	instanceStateClosed = "Closed"
+
+	// maskedSecretValue replaces secret env values in responses.
+	maskedSecretValue = "********"
+)
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /v1/api-keys/scope", s.handleTokenScope)
+	s.mux.HandleFunc("GET /v1/organization/{id}", s.handleGetOrganization)
+	s.mux.HandleFunc("GET /v1/organization/{id}/clusters", s.handleGetOrganizationClusters)
+	s.mux.HandleFunc("GET /v1/organization/{id}/cluster-instances", s.handleGetOrganizationClusterInstances)
+
+	s.mux.HandleFunc("POST /v1/cluster", s.handleCreateCluster)
+	s.mux.HandleFunc("GET /v1/cluster/{id}", s.handleGetCluster)
+	s.mux.HandleFunc("PATCH /v1/cluster/{id}", s.handleUpdateCluster)
+	s.mux.HandleFunc("DELETE /v1/cluster/{id}", s.handleDeleteCluster)
+	s.mux.HandleFunc("GET /v1/cluster/{id}/instances", s.handleGetClusterInstances)
+
+	s.mux.HandleFunc("POST /v1/cluster-instance/create", s.handleCreateInstance)
+	s.mux.HandleFunc("POST /v1/cluster-instance/template", s.handleCreateInstanceFromTemplate)
+	s.mux.HandleFunc("GET /v1/cluster-instance/{id}", s.handleGetInstance)
+	s.mux.HandleFunc("POST /v1/cluster-instance/{id}/close", s.handleCloseInstance)
+	s.mux.HandleFunc("PATCH /v1/cluster-instance/{id}/update", s.handleUpdateInstance)
+	s.mux.HandleFunc("PATCH /v1/cluster-instance/{id}/update/health-check", s.handleUpdateHealthCheck)
+	// Orders and domain lists share the same path shape, so they are
+	// dispatched by a single handler.
+	s.mux.HandleFunc("GET /v1/cluster-instance/{first}/{second}", s.handleGetOrderOrDomains)
+	s.mux.HandleFunc("POST /v1/cluster-instance/{id}/domains", s.handleAddDomain)
+	s.mux.HandleFunc("PATCH /v1/cluster-instance/{id}/domains/{domainId}", s.handleUpdateDomain)
+	s.mux.HandleFunc("DELETE /v1/cluster-instance/{id}/domains/{domainId}", s.handleDeleteDomain)
+
+	s.mux.HandleFunc("GET /v1/cluster-templates", s.handleGetTemplates)
+	s.mux.HandleFunc("GET /v1/compute-machine-image", s.handleGetComputeMachines)
+	s.mux.HandleFunc("GET /v1/compute-machine-image/regions", s.handleGetRegions)
+	s.mux.HandleFunc("GET /v1/compute-machine-image/limits", s.handleGetLimits)
+	s.mux.HandleFunc("GET /v1/compute-machine-image/pricing", s.handleGetPricing)
+
+	s.mux.HandleFunc("GET /v1/subscribe", s.handleSubscribe)
+}
+
+func (s *Server) handleTokenScope(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, client.TokenScope{
+		User: client.User{ID: "user", Username: "fake", Name: "Fake User"},
+		Organizations: []client.TokenOrganization{{
+			ID:       s.organization.ID,
+			Name:     s.organization.Profile.Name,
+			Username: s.organization.Profile.Username,
+		}},
+	})
+}
+
+// checkOrganization reports a not found error for organizations other than
+// the one the token is scoped to.
+func (s *Server) checkOrganization(w http.ResponseWriter, id string) bool {
+	if id != s.organization.ID {
+		writeError(w, http.StatusNotFound, "Organization not found")
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleGetOrganization(w http.ResponseWriter, r *http.Request) {
+	if !s.checkOrganization(w, r.PathValue("id")) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.organization)
+}
+
+func (s *Server) handleGetOrganizationClusters(w http.ResponseWriter, r *http.Request) {
+	if !s.checkOrganization(w, r.PathValue("id")) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"clusters": s.Clusters()})
+}
+
+func (s *Server) handleGetOrganizationClusterInstances(w http.ResponseWriter, r *http.Request) {
+	if !s.checkOrganization(w, r.PathValue("id")) {
+		return
+	}
+
+	instances := s.Instances()
+	start, end := paginate(r, len(instances))
+	writeJSON(w, http.StatusOK, map[string]any{"clusterInstances": instances[start:end]})
+}
+
+func (s *Server) handleCreateCluster(w http.ResponseWriter, r *http.Request) {
+	var request client.CreateClusterRequest
+	if !decodeJSON(w, r, &request) || !s.checkOrganization(w, request.OrganizationID) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findClusterByName(request.Name) != nil {
+		writeError(w, http.StatusBadRequest, "Cluster with this name already exists")
+		return
+	}
+
+	cluster := s.createCluster(request.Name)
+	writeJSON(w, http.StatusOK, map[string]any{"cluster": cluster})
+}
+
+func (s *Server) createCluster(name string) *client.Cluster {
+	cluster := &client.Cluster{ID: s.newID(), Name: name}
+	s.clusters = append(s.clusters, cluster)
+	return cluster
+}
+
+func (s *Server) handleGetCluster(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster := s.findCluster(r.PathValue("id"))
+	if cluster == nil {
+		writeError(w, http.StatusNotFound, "Cluster not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"cluster": cluster})
+}
+
+func (s *Server) handleUpdateCluster(w http.ResponseWriter, r *http.Request) {
+	var request client.UpdateClusterRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster := s.findCluster(r.PathValue("id"))
+	if cluster == nil {
+		writeError(w, http.StatusNotFound, "Cluster not found")
+		return
+	}
+
+	if other := s.findClusterByName(request.Name); other != nil && other != cluster {
+		writeError(w, http.StatusBadRequest, "Cluster with this name already exists")
+		return
+	}
+
+	cluster.Name = request.Name
+	writeJSON(w, http.StatusOK, map[string]any{"cluster": cluster})
+}
+
+func (s *Server) handleDeleteCluster(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	for i, cluster := range s.clusters {
+		if cluster.ID != id {
+			continue
+		}
+
+		for _, instance := range s.instances {
+			if instance.Cluster == id && instance.State != instanceStateClosed {
+				writeError(w, http.StatusBadRequest, "Cluster has active instances")
+				return
+			}
+		}
+
+		s.clusters = append(s.clusters[:i], s.clusters[i+1:]...)
+		writeJSON(w, http.StatusOK, client.GenericResponse{Message: "Cluster deleted", Success: true})
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "Cluster not found")
+}
+
+func (s *Server) handleGetClusterInstances(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	if s.findCluster(id) == nil {
+		writeError(w, http.StatusNotFound, "Cluster not found")
+		return
+	}
+
+	instances := []client.Instance{}
+	for _, instance := range s.instances {
+		if instance.Cluster == id {
+			instances = append(instances, *instance)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"instances": instances})
+}
+
+func (s *Server) handleCreateInstance(w http.ResponseWriter, r *http.Request) {
+	var request client.CreateInstanceRequest
+	if !decodeJSON(w, r, &request) || !s.checkOrganization(w, request.OrganizationID) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cluster *client.Cluster
+	if request.ClusterID != "" {
+		cluster = s.findCluster(request.ClusterID)
+		if cluster == nil {
+			writeError(w, http.StatusNotFound, "Cluster not found")
+			return
+		}
+	} else {
+		cluster = s.findClusterByName(request.ClusterName)
+		if cluster == nil {
+			cluster = s.createCluster(request.ClusterName)
+		}
+	}
+
+	config := request.Configuration
+	machineImage, err := s.agreedMachineImage(config.AkashMachineImageName, "", config.CustomInstanceSpecs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	healthCheckPort, _ := strconv.Atoi(request.HealthCheckPort)
+	healthCheck := client.HealthCheck{
+		URL:  request.HealthCheckURL,
+		Port: client.Port{ContainerPort: healthCheckPort},
+	}
+
+	s.deploy(w, cluster, request.InstanceName, request.UniqueTopicID, healthCheck, client.ClusterInstanceConfiguration{
+		Image:              config.Image,
+		Tag:                config.Tag,
+		Ports:              config.Ports,
+		Env:                config.Env,
+		Command:            config.Command,
+		Args:               config.Args,
+		Region:             config.Region,
+		AgreedMachineImage: machineImage,
+		InstanceCount:      config.InstanceCount,
+	})
+}
+
+func (s *Server) handleCreateInstanceFromTemplate(w http.ResponseWriter, r *http.Request) {
+	var request client.CreateInstanceFromMarketplaceRequest
+	if !decodeJSON(w, r, &request) || !s.checkOrganization(w, request.OrganizationID) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var template *client.MarketplaceApp
+	for i := range s.templates {
+		if s.templates[i].ID == request.TemplateID {
+			template = &s.templates[i]
+		}
+	}
+	if template == nil {
+		writeError(w, http.StatusNotFound, "Template not found")
+		return
+	}
+
+	envs := make([]client.Env, 0, len(request.EnvironmentVariables))
+	for _, variable := range request.EnvironmentVariables {
+		for _, appVar := range template.ServiceData.Variables {
+			if appVar.Label == variable.Label {
+				envs = append(envs, client.Env{Value: appVar.Name + "=" + variable.Value})
+			}
+		}
+	}
+
+	machineImage, err := s.agreedMachineImage("", request.AkashImageID, request.CustomInstanceSpecs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cluster := s.findClusterByName(template.Name)
+	if cluster == nil {
+		cluster = s.createCluster(template.Name)
+	}
+
+	s.deploy(w, cluster, request.InstanceName, request.UniqueTopicID, client.HealthCheck{}, client.ClusterInstanceConfiguration{
+		Image:              strings.ToLower(template.Name),
+		Tag:                "latest",
+		Ports:              []client.Port{{ContainerPort: 5432}},
+		Env:                envs,
+		Region:             request.Region,
+		AgreedMachineImage: machineImage,
+		InstanceCount:      request.InstanceCount,
+		TemplateID:         template.ID,
+	})
+}
+
+// agreedMachineImage resolves the machine image of a deployment, either by
+// name, by id or from custom specs when neither is set.
+func (s *Server) agreedMachineImage(name string, id string, specs client.CustomInstanceSpecs) (client.MachineImageType, error) {
+	machineImage := client.MachineImageType{Storage: specs.Storage}
+	if specs.PersistentStorage.Class != "" {
+		persistentStorage := specs.PersistentStorage
+		machineImage.PersistentStorage = &persistentStorage
+	}
+
+	var machine *client.ComputeMachine
+	switch {
+	case name != "":
+		machine = s.findMachineByName(name)
+	case id != "":
+		machine = s.findMachineByID(id)
+	default:
+		machineImage.MachineType = "Custom Plan"
+		machineImage.Cpu = float32(parseGi(specs.CPU))
+		machineImage.Memory = specs.Memory
+		return machineImage, nil
+	}
+
+	if machine == nil {
+		return machineImage, fmt.Errorf("Machine image not found")
+	}
+
+	machineImage.MachineType = machine.Name
+	machineImage.Cpu = machine.Cpu
+	machineImage.Memory = machine.Memory
+	return machineImage, nil
+}
+
+// deploy creates an instance with its first order and publishes the deployed
+// event. Callers must hold the lock.
+func (s *Server) deploy(w http.ResponseWriter, cluster *client.Cluster, name string, topicID string, healthCheck client.HealthCheck, config client.ClusterInstanceConfiguration) {
+	instanceID := s.newID()
+	if name == "" {
+		name = fmt.Sprintf("instance-%s", instanceID[len(instanceID)-6:])
+	}
+
+	for _, instance := range s.instances {
+		if instance.Cluster == cluster.ID && instance.Name == name && instance.State != instanceStateClosed {
+			writeError(w, http.StatusBadRequest, "Instance with this name already exists in the cluster")
+			return
+		}
+	}
+
+	config.Ports = s.exposePorts(config.Ports)
+	order := s.createOrder(instanceID, config)
+
+	createdAt := now()
+	instance := &client.Instance{
+		ID:                     instanceID,
+		State:                  instanceStateActive,
+		Name:                   name,
+		Orders:                 []string{order.ID},
+		Cluster:                cluster.ID,
+		ActiveOrder:            order.ID,
+		LatestURLPreview:       order.URLPreview,
+		AgreedMachineImageType: config.AgreedMachineImage,
+		HealthCheck:            healthCheck,
+		CreatedAt:              createdAt,
+		UpdatedAt:              createdAt,
+	}
+	s.instances = append(s.instances, instance)
+
+	s.publishDeployment(topicID, instance, order)
+
+	writeJSON(w, http.StatusOK, client.InstanceResponse{
+		ClusterID:              cluster.ID,
+		ClusterInstanceID:      instance.ID,
+		ClusterInstanceOrderID: order.ID,
+		Topic:                  topicID,
+	})
+}
+
+// exposePorts assigns an exposed port to ports which don't have one.
+func (s *Server) exposePorts(ports []client.Port) []client.Port {
+	exposed := make([]client.Port, 0, len(ports))
+	for i, port := range ports {
+		if port.ExposedPort == 0 {
+			port.ExposedPort = 30000 + i
+		}
+		exposed = append(exposed, port)
+	}
+	return exposed
+}
+
+func (s *Server) createOrder(instanceID string, config client.ClusterInstanceConfiguration) *client.InstanceOrder {
+	order := &client.InstanceOrder{
+		ID:                           s.newID(),
+		Status:                       "DEPLOYED",
+		URLPreview:                   fmt.Sprintf("https://%s.fake.spheron.local", instanceID),
+		ProtocolData:                 &client.ProtocolData{ProviderHost: ProviderHost},
+		ClusterInstanceConfiguration: &config,
+	}
+	s.orders[order.ID] = order
+	return order
+}
+
+// maskOrderSecrets returns a copy of an order with the values of secret envs
+// masked, as the Spheron API never returns them.
+func maskOrderSecrets(order *client.InstanceOrder) *client.InstanceOrder {
+	masked := *order
+	config := *order.ClusterInstanceConfiguration
+	config.Env = make([]client.Env, 0, len(order.ClusterInstanceConfiguration.Env))
+	for _, env := range order.ClusterInstanceConfiguration.Env {
+		if env.IsSecret {
+			key, _, _ := strings.Cut(env.Value, "=")
+			env.Value = key + "=" + maskedSecretValue
+		}
+		config.Env = append(config.Env, env)
+	}
+	masked.ClusterInstanceConfiguration = &config
+	return &masked
+}
+
+func (s *Server) handleGetInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instance := s.findInstance(r.PathValue("id"))
+	if instance == nil {
+		writeError(w, http.StatusNotFound, "Instance not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, client.GetClusterInstanceResponse{Success: true, Instance: *instance})
+}
+
+func (s *Server) handleCloseInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instance := s.findInstance(r.PathValue("id"))
+	if instance == nil {
+		writeError(w, http.StatusNotFound, "Instance not found")
+		return
+	}
+
+	if instance.State == instanceStateClosed {
+		writeError(w, http.StatusBadRequest, "Instance already closed")
+		return
+	}
+
+	instance.State = instanceStateClosed
+	instance.UpdatedAt = now()
+	writeJSON(w, http.StatusOK, client.GenericResponse{Message: "Instance closed", Success: true})
+}
+
+func (s *Server) handleUpdateInstance(w http.ResponseWriter, r *http.Request) {
+	var request client.UpdateInstanceRequest
+	if !decodeJSON(w, r, &request) || !s.checkOrganization(w, request.OrganizationID) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instance := s.findInstance(r.PathValue("id"))
+	if instance == nil {
+		writeError(w, http.StatusNotFound, "Instance not found")
+		return
+	}
+
+	if instance.State == instanceStateClosed {
+		writeError(w, http.StatusBadRequest, "Instance is closed")
+		return
+	}
+
+	config := *s.orders[instance.ActiveOrder].ClusterInstanceConfiguration
+	config.Env = request.Env
+	config.Command = request.Command
+	config.Args = request.Args
+	config.Tag = request.Tag
+
+	order := s.createOrder(instance.ID, config)
+	instance.Orders = append(instance.Orders, order.ID)
+	instance.ActiveOrder = order.ID
+	instance.LatestURLPreview = order.URLPreview
+	instance.UpdatedAt = now()
+
+	s.publishDeployment(request.UniqueTopicID, instance, order)
+
+	writeJSON(w, http.StatusOK, client.InstanceResponse{
+		ClusterID:              instance.Cluster,
+		ClusterInstanceID:      instance.ID,
+		ClusterInstanceOrderID: order.ID,
+		Topic:                  request.UniqueTopicID,
+	})
+}
+
+func (s *Server) handleUpdateHealthCheck(w http.ResponseWriter, r *http.Request) {
+	var request client.HealthCheckUpdateReq
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instance := s.findInstance(r.PathValue("id"))
+	if instance == nil {
+		writeError(w, http.StatusNotFound, "Instance not found")
+		return
+	}
+
+	instance.HealthCheck = client.HealthCheck{
+		URL:  request.HealthCheckURL,
+		Port: client.Port{ContainerPort: request.HealthCheckPort},
+	}
+	writeJSON(w, http.StatusOK, client.GenericResponse{Message: "Health check updated", Updated: true})
+}
+
+func (s *Server) handleGetOrderOrDomains(w http.ResponseWriter, r *http.Request) {
+	first, second := r.PathValue("first"), r.PathValue("second")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case first == "order":
+		order, ok := s.orders[second]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Order not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"order": maskOrderSecrets(order), "liveLogs": []string{}})
+	case second == "domains":
+		if s.findInstance(first) == nil {
+			writeError(w, http.StatusNotFound, "Instance not found")
+			return
+		}
+
+		domains := s.domains[first]
+		if domains == nil {
+			domains = []client.Domain{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"domains": domains})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleAddDomain(w http.ResponseWriter, r *http.Request) {
+	var request client.DomainRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instanceID := r.PathValue("id")
+	if s.findInstance(instanceID) == nil {
+		writeError(w, http.StatusNotFound, "Instance not found")
+		return
+	}
+
+	domain := client.Domain{
+		ID:         s.newID(),
+		Name:       request.Name,
+		Link:       request.Link,
+		Type:       request.Type,
+		InstanceID: instanceID,
+	}
+	s.domains[instanceID] = append(s.domains[instanceID], domain)
+
+	writeJSON(w, http.StatusOK, client.DomainResponse{Domain: domain})
+}
+
+func (s *Server) handleUpdateDomain(w http.ResponseWriter, r *http.Request) {
+	var request client.DomainRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domains := s.domains[r.PathValue("id")]
+	for i := range domains {
+		if domains[i].ID != r.PathValue("domainId") {
+			continue
+		}
+
+		domains[i].Name = request.Name
+		domains[i].Link = request.Link
+		domains[i].Type = request.Type
+		domains[i].Verified = false
+
+		writeJSON(w, http.StatusOK, client.DomainResponse{Domain: domains[i]})
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "Domain not found")
+}
+
+func (s *Server) handleDeleteDomain(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instanceID := r.PathValue("id")
+	domains := s.domains[instanceID]
+	for i := range domains {
+		if domains[i].ID != r.PathValue("domainId") {
+			continue
+		}
+
+		s.domains[instanceID] = append(domains[:i], domains[i+1:]...)
+		writeJSON(w, http.StatusOK, client.GenericResponse{Message: "Domain deleted", Success: true})
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "Domain not found")
+}
+
+func (s *Server) handleGetTemplates(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"clusterTemplates": s.templates})
+}
+
+func (s *Server) handleGetComputeMachines(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start, end := paginate(r, len(s.machines))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"akashMachineImages": s.machines[start:end],
+		"totalCount":         len(s.machines),
+	})
+}
+
+func (s *Server) handleGetRegions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"regions": s.regions})
+}
+
+func (s *Server) handleGetLimits(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"limits": s.limits})
+}
+
+func (s *Server) handleGetPricing(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"pricing": s.pricing})
+}
+
+// handleSubscribe streams the deployment event of a topic as server-sent
+// events, waiting until the deployment is published.
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	topicID := r.URL.Query().Get("sessionId")
+	if topicID == "" {
+		writeError(w, http.StatusBadRequest, "Missing sessionId")
+		return
+	}
+
+	s.mu.Lock()
+	t := s.getTopic(topicID)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	select {
+	case <-t.published:
+	case <-r.Context().Done():
+		return
+	}
+
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", t.event)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,401 @@
+// Package fakeapi implements an in-memory fake of the Spheron API endpoints
+// used by the provider client, so the provider can be exercised offline.
+//
+// Deployments complete instantly, the deployed event is published on the
+// topic passed with the create or update request. Like the real API, orders
+// are returned with secret env values masked.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"terraform-provider-spherontest/internal/client"
+)
+
+// ProviderHost is the provider host reported for all deployments.
+const ProviderHost = "provider.fake.spheron.local"
+
+// Server is an http.Handler serving the fake Spheron API.
+type Server struct {
+	token        string
+	organization client.Organization
+
+	mu              sync.Mutex
+	nextID          int
+	clusters        []*client.Cluster
+	instances       []*client.Instance
+	orders          map[string]*client.InstanceOrder
+	domains         map[string][]client.Domain
+	templates       []client.MarketplaceApp
+	machines        []client.ComputeMachine
+	regions         []string
+	limits          client.ComputeLimits
+	pricing         client.ComputePricing
+	topics          map[string]*topic
+	failDeployments bool
+	// removedRoutes are answered with 404 Not Found, as by an API which
+	// doesn't serve them.
+	removedRoutes map[string]bool
+	// routeRequests counts the requests received by route pattern.
+	routeRequests map[string]int
+	// userAgents are the distinct User-Agent headers received, in order.
+	userAgents []string
+
+	requests atomic.Int64
+	mux      *http.ServeMux
+}
+
+// topic holds the deployment event of a create or update request.
+type topic struct {
+	published chan struct{}
+	event     string
+}
+
+// New returns a fake API accepting the given token, seeded with a single
+// organization, compute machines, regions, pricing and a Postgres
+// marketplace app.
+func New(token string) *Server {
+	s := &Server{
+		token:   token,
+		orders:  map[string]*client.InstanceOrder{},
+		domains: map[string][]client.Domain{},
+		topics:  map[string]*topic{},
+
+		removedRoutes: map[string]bool{},
+		routeRequests: map[string]int{},
+		machines: []client.ComputeMachine{
+			{Name: "Ventus Nano", Cpu: 0.5, Memory: "1Gi"},
+			{Name: "Ventus Small", Cpu: 1, Memory: "2Gi"},
+			{Name: "Ventus Medium", Cpu: 2, Memory: "4Gi"},
+		},
+		regions: []string{"us-east", "us-west", "eu-central"},
+		limits: client.ComputeLimits{
+			Cpu:                  []string{"0.5", "1", "2", "4", "8", "16", "32"},
+			Memory:               []string{"0.5", "1", "2", "4", "8", "16", "32"},
+			MaxStorage:           1024,
+			MaxPersistentStorage: 1024,
+		},
+		pricing: client.ComputePricing{
+			MachineImages: map[string]float64{
+				"Ventus Nano":   0.01,
+				"Ventus Small":  0.02,
+				"Ventus Medium": 0.04,
+			},
+			CpuPerHour:     0.015,
+			MemoryPerHour:  0.005,
+			StoragePerHour: 0.0001,
+			PersistentStoragePerHour: map[string]float64{
+				"HDD":  0.0001,
+				"SSD":  0.0002,
+				"NVMe": 0.0003,
+			},
+		},
+		templates: []client.MarketplaceApp{
+			{
+				Name: "Postgres",
+				ServiceData: client.MarketplaceAppServiceData{
+					Variables: []client.MarketplaceAppVariable{
+						{Name: "POSTGRES_PASSWORD", Label: "Password", Required: true},
+						{Name: "POSTGRES_USER", Label: "User", DefaultValue: "postgres"},
+						{Name: "POSTGRES_DB", Label: "Database", DefaultValue: "postgres"},
+					},
+				},
+			},
+		},
+	}
+
+	s.organization.ID = s.newID()
+	s.organization.Profile.Name = "Fake Organization"
+	s.organization.Profile.Username = "fake"
+
+	for i := range s.machines {
+		s.machines[i].ID = s.newID()
+	}
+	for i := range s.templates {
+		s.templates[i].ID = s.newID()
+	}
+
+	s.mux = http.NewServeMux()
+	s.routes()
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Request-Id", fmt.Sprintf("fake-%d", s.requests.Add(1)))
+
+	s.mu.Lock()
+	if !slices.Contains(s.userAgents, r.UserAgent()) {
+		s.userAgents = append(s.userAgents, r.UserAgent())
+	}
+	s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		writeError(w, http.StatusUnauthorized, "Invalid access token")
+		return
+	}
+
+	_, pattern := s.mux.Handler(r)
+	s.mu.Lock()
+	s.routeRequests[pattern]++
+	removed := s.removedRoutes[pattern]
+	s.mu.Unlock()
+	if removed {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// OrganizationID returns the id of the organization the token is scoped to.
+func (s *Server) OrganizationID() string {
+	return s.organization.ID
+}
+
+// AddComputeMachine adds a compute machine to the catalog and returns its id.
+func (s *Server) AddComputeMachine(machine client.ComputeMachine, pricePerHour float64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	machine.ID = s.newID()
+	s.machines = append(s.machines, machine)
+	s.pricing.MachineImages[machine.Name] = pricePerHour
+	return machine.ID
+}
+
+// AddTemplate adds a marketplace app and returns its id.
+func (s *Server) AddTemplate(app client.MarketplaceApp) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app.ID = s.newID()
+	s.templates = append(s.templates, app)
+	return app.ID
+}
+
+// SetFailDeployments makes following deployments publish a failed event.
+func (s *Server) SetFailDeployments(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failDeployments = fail
+}
+
+// RemoveRoute makes the route registered with pattern, like
+// "GET /v1/compute-machine-image/pricing", answer 404 Not Found as if the API
+// didn't serve it.
+func (s *Server) RemoveRoute(pattern string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removedRoutes[pattern] = true
+}
+
+// RouteRequests returns the number of requests received by the route
+// registered with pattern, including requests answered 404 Not Found after
+// RemoveRoute.
+func (s *Server) RouteRequests(pattern string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.routeRequests[pattern]
+}
+
+// UserAgents returns the distinct User-Agent headers of the requests received,
+// in the order they were first sent.
+func (s *Server) UserAgents() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.userAgents)
+}
+
+// Instances returns a copy of all instances, including closed ones.
+func (s *Server) Instances() []client.Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instances := make([]client.Instance, 0, len(s.instances))
+	for _, instance := range s.instances {
+		instances = append(instances, *instance)
+	}
+	return instances
+}
+
+// Order returns a copy of an order with unmasked secret env values, which the
+// API never returns.
+func (s *Server) Order(id string) (client.InstanceOrder, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return client.InstanceOrder{}, false
+	}
+
+	copied := *order
+	config := *order.ClusterInstanceConfiguration
+	config.Env = slices.Clone(config.Env)
+	copied.ClusterInstanceConfiguration = &config
+	return copied, true
+}
+
+// Clusters returns a copy of all clusters.
+func (s *Server) Clusters() []client.Cluster {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clusters := make([]client.Cluster, 0, len(s.clusters))
+	for _, cluster := range s.clusters {
+		clusters = append(clusters, *cluster)
+	}
+	return clusters
+}
+
+// newID returns a unique id in the format of Spheron ids. Callers must hold
+// the lock once the server is serving requests.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("%024x", s.nextID)
+}
+
+func (s *Server) findCluster(id string) *client.Cluster {
+	for _, cluster := range s.clusters {
+		if cluster.ID == id {
+			return cluster
+		}
+	}
+	return nil
+}
+
+func (s *Server) findClusterByName(name string) *client.Cluster {
+	for _, cluster := range s.clusters {
+		if cluster.Name == name {
+			return cluster
+		}
+	}
+	return nil
+}
+
+func (s *Server) findInstance(id string) *client.Instance {
+	for _, instance := range s.instances {
+		if instance.ID == id {
+			return instance
+		}
+	}
+	return nil
+}
+
+func (s *Server) findMachineByName(name string) *client.ComputeMachine {
+	for i := range s.machines {
+		if s.machines[i].Name == name {
+			return &s.machines[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) findMachineByID(id string) *client.ComputeMachine {
+	for i := range s.machines {
+		if s.machines[i].ID == id {
+			return &s.machines[i]
+		}
+	}
+	return nil
+}
+
+// getTopic returns the topic with the given id, creating it if needed as the
+// subscription may come before or after the deployment.
+func (s *Server) getTopic(id string) *topic {
+	t, ok := s.topics[id]
+	if !ok {
+		t = &topic{published: make(chan struct{})}
+		s.topics[id] = t
+	}
+	return t
+}
+
+// publishDeployment publishes the deployment event of an order on a topic.
+func (s *Server) publishDeployment(topicID string, instance *client.Instance, order *client.InstanceOrder) {
+	if topicID == "" {
+		return
+	}
+
+	eventType := 2
+	status := "DEPLOYED"
+	if s.failDeployments {
+		eventType = 3
+		status = "FAILED"
+	}
+
+	event := map[string]any{
+		"type": eventType,
+		"data": map[string]any{
+			"deploymentStatus": status,
+			"latestUrlPreview": instance.LatestURLPreview,
+			"providerHost":     ProviderHost,
+			"ports":            order.ClusterInstanceConfiguration.Ports,
+		},
+		"session": topicID,
+	}
+	eventJSON, _ := json.Marshal(event)
+
+	t := s.getTopic(topicID)
+	select {
+	case <-t.published:
+		// Topics are unique per request, a second publish is ignored.
+	default:
+		t.event = string(eventJSON)
+		close(t.published)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, value any) bool {
+	if err := json.NewDecoder(r.Body).Decode(value); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %s", err.Error()))
+		return false
+	}
+	return true
+}
+
+// paginate applies the skip and limit query params to a list length and
+// returns the bounds of the requested page.
+func paginate(r *http.Request, length int) (int, int) {
+	skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = length
+	}
+
+	start := min(max(skip, 0), length)
+	end := min(start+limit, length)
+	return start, end
+}
+
+func parseGi(value string) float64 {
+	size, _ := strconv.ParseFloat(strings.TrimSuffix(value, "Gi"), 32)
+	return size
+}
+
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
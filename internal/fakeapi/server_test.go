@@ -0,0 +1,343 @@
+package fakeapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/fakeapi"
+)
+
+const testToken = "test-token"
+
+func newTestServer(t *testing.T) (*fakeapi.Server, *httptest.Server, *client.SpheronApi) {
+	t.Helper()
+
+	api := fakeapi.New(testToken)
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	spheronApi, err := client.NewSpheronApi(testToken, server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return api, server, spheronApi
+}
+
+func createInstance(t *testing.T, api *fakeapi.Server, spheronApi *client.SpheronApi, name string, topicID string) client.InstanceResponse {
+	t.Helper()
+
+	response, err := spheronApi.CreateClusterInstance(context.Background(), client.CreateInstanceRequest{
+		OrganizationID: api.OrganizationID(),
+		UniqueTopicID:  topicID,
+		InstanceName:   name,
+		ClusterName:    "cluster",
+		Configuration: client.InstanceConfiguration{
+			Image:                 "nginx",
+			Tag:                   "1.25",
+			InstanceCount:         1,
+			Ports:                 []client.Port{{ContainerPort: 80}},
+			Env:                   []client.Env{{Value: "A=1"}},
+			Region:                "us-east",
+			AkashMachineImageName: "Ventus Nano",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance: %s", err)
+	}
+
+	return response
+}
+
+func TestInstanceOrders(t *testing.T) {
+	ctx := context.Background()
+	api, _, spheronApi := newTestServer(t)
+
+	created := createInstance(t, api, spheronApi, "web", "create-topic")
+
+	event, err := spheronApi.WaitForDeployedEvent(ctx, "create-topic")
+	if err != nil {
+		t.Fatalf("unexpected error waiting for deployment: %s", err)
+	}
+	if !strings.Contains(event, `"deploymentStatus":"DEPLOYED"`) {
+		t.Errorf("expected deployed event, got %s", event)
+	}
+
+	instance, err := spheronApi.GetClusterInstance(ctx, created.ClusterInstanceID)
+	if err != nil {
+		t.Fatalf("unexpected error getting instance: %s", err)
+	}
+	if instance.State != "Active" || instance.Name != "web" || instance.Cluster != created.ClusterID {
+		t.Errorf("unexpected instance: %+v", instance)
+	}
+	if instance.ActiveOrder != created.ClusterInstanceOrderID {
+		t.Errorf("expected active order %s, got %s", created.ClusterInstanceOrderID, instance.ActiveOrder)
+	}
+
+	order, err := spheronApi.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
+	if err != nil {
+		t.Fatalf("unexpected error getting order: %s", err)
+	}
+	config := order.ClusterInstanceConfiguration
+	if config.Image != "nginx" || config.Tag != "1.25" || config.AgreedMachineImage.MachineType != "Ventus Nano" {
+		t.Errorf("unexpected order configuration: %+v", config)
+	}
+	if len(config.Ports) != 1 || config.Ports[0].ExposedPort == 0 {
+		t.Errorf("expected an exposed port to be assigned, got %+v", config.Ports)
+	}
+
+	_, err = spheronApi.UpdateClusterInstance(ctx, instance.ID, client.UpdateInstanceRequest{
+		OrganizationID: api.OrganizationID(),
+		UniqueTopicID:  "update-topic",
+		Tag:            "1.26",
+		Env:            []client.Env{{Value: "A=2"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error updating instance: %s", err)
+	}
+
+	updated, err := spheronApi.GetClusterInstance(ctx, instance.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting instance: %s", err)
+	}
+	if len(updated.Orders) != 2 || updated.ActiveOrder == instance.ActiveOrder {
+		t.Errorf("expected the update to create a new active order, got %+v", updated)
+	}
+
+	updatedOrder, err := spheronApi.GetClusterInstanceOrder(ctx, updated.ActiveOrder)
+	if err != nil {
+		t.Fatalf("unexpected error getting order: %s", err)
+	}
+	if updatedOrder.ClusterInstanceConfiguration.Tag != "1.26" || updatedOrder.ClusterInstanceConfiguration.Env[0].Value != "A=2" {
+		t.Errorf("unexpected updated order configuration: %+v", updatedOrder.ClusterInstanceConfiguration)
+	}
+
+	if _, err := spheronApi.CloseClusterInstance(ctx, instance.ID); err != nil {
+		t.Fatalf("unexpected error closing instance: %s", err)
+	}
+
+	closed, err := spheronApi.GetClusterInstance(ctx, instance.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting closed instance: %s", err)
+	}
+	if closed.State != "Closed" {
+		t.Errorf("expected closed instance, got state %s", closed.State)
+	}
+
+	if _, err := spheronApi.CloseClusterInstance(ctx, instance.ID); err == nil || err.Error() != "Instance already closed" {
+		t.Errorf("expected closing twice to fail, got %v", err)
+	}
+
+	_, err = spheronApi.UpdateClusterInstance(ctx, instance.ID, client.UpdateInstanceRequest{
+		OrganizationID: api.OrganizationID(),
+	})
+	if err == nil || err.Error() != "Instance is closed" {
+		t.Errorf("expected updating a closed instance to fail, got %v", err)
+	}
+
+	if _, err := spheronApi.GetClusterInstance(ctx, "missing"); err == nil || err.Error() != "Instance not found" {
+		t.Errorf("expected missing instance error, got %v", err)
+	}
+}
+
+func TestSecretEnvsMasked(t *testing.T) {
+	ctx := context.Background()
+	api, _, spheronApi := newTestServer(t)
+
+	created := createInstance(t, api, spheronApi, "web", "")
+
+	_, err := spheronApi.UpdateClusterInstance(ctx, created.ClusterInstanceID, client.UpdateInstanceRequest{
+		OrganizationID: api.OrganizationID(),
+		Tag:            "1.25",
+		Env:            []client.Env{{Value: "A=1"}, {Value: "S=secret", IsSecret: true}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error updating instance: %s", err)
+	}
+
+	instance, err := spheronApi.GetClusterInstance(ctx, created.ClusterInstanceID)
+	if err != nil {
+		t.Fatalf("unexpected error getting instance: %s", err)
+	}
+
+	order, err := spheronApi.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
+	if err != nil {
+		t.Fatalf("unexpected error getting order: %s", err)
+	}
+
+	expected := []client.Env{{Value: "A=1"}, {Value: "S=********", IsSecret: true}}
+	if !slices.Equal(order.ClusterInstanceConfiguration.Env, expected) {
+		t.Errorf("expected secret values to be masked, got %+v", order.ClusterInstanceConfiguration.Env)
+	}
+
+	stored, ok := api.Order(instance.ActiveOrder)
+	if !ok {
+		t.Fatalf("order %s not found", instance.ActiveOrder)
+	}
+	if stored.ClusterInstanceConfiguration.Env[1].Value != "S=secret" {
+		t.Errorf("expected the stored order to keep the secret, got %+v", stored.ClusterInstanceConfiguration.Env)
+	}
+}
+
+func TestFailedDeployment(t *testing.T) {
+	api, _, spheronApi := newTestServer(t)
+	api.SetFailDeployments(true)
+
+	createInstance(t, api, spheronApi, "web", "topic")
+
+	if _, err := spheronApi.WaitForDeployedEvent(context.Background(), "topic"); err == nil {
+		t.Error("expected failed deployment error")
+	}
+}
+
+func TestInstancesPagination(t *testing.T) {
+	api, server, spheronApi := newTestServer(t)
+
+	for i := 0; i < 5; i++ {
+		createInstance(t, api, spheronApi, fmt.Sprintf("web-%d", i), "")
+	}
+
+	testCases := map[string]struct {
+		query       string
+		expectedIDs []int
+	}{
+		"all":           {query: "", expectedIDs: []int{0, 1, 2, 3, 4}},
+		"first-page":    {query: "skip=0&limit=2", expectedIDs: []int{0, 1}},
+		"second-page":   {query: "skip=2&limit=2", expectedIDs: []int{2, 3}},
+		"last-page":     {query: "skip=4&limit=2", expectedIDs: []int{4}},
+		"past-the-end":  {query: "skip=10&limit=2", expectedIDs: []int{}},
+		"negative-skip": {query: "skip=-1&limit=1", expectedIDs: []int{0}},
+		"no-limit":      {query: "skip=3", expectedIDs: []int{3, 4}},
+	}
+
+	instances := api.Instances()
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/v1/organization/%s/cluster-instances?%s", server.URL, api.OrganizationID(), testCase.query)
+			request, _ := http.NewRequest(http.MethodGet, url, nil)
+			request.Header.Set("Authorization", "Bearer "+testToken)
+
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer response.Body.Close()
+
+			var page struct {
+				ClusterInstances []client.Instance `json:"clusterInstances"`
+			}
+			if err := json.NewDecoder(response.Body).Decode(&page); err != nil {
+				t.Fatalf("unexpected error decoding page: %s", err)
+			}
+
+			if len(page.ClusterInstances) != len(testCase.expectedIDs) {
+				t.Fatalf("expected %d instances, got %d", len(testCase.expectedIDs), len(page.ClusterInstances))
+			}
+			for i, index := range testCase.expectedIDs {
+				if page.ClusterInstances[i].ID != instances[index].ID {
+					t.Errorf("expected instance %d at position %d, got %s", index, i, page.ClusterInstances[i].Name)
+				}
+			}
+		})
+	}
+
+	all, err := spheronApi.GetOrganizationClusterInstances(context.Background(), api.OrganizationID())
+	if err != nil {
+		t.Fatalf("unexpected error listing instances: %s", err)
+	}
+	if len(all) != 5 {
+		t.Errorf("expected the client to list 5 instances, got %d", len(all))
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	api, server, _ := newTestServer(t)
+
+	testCases := map[string]struct {
+		authorization  string
+		expectedStatus int
+	}{
+		"valid-token":   {authorization: "Bearer " + testToken, expectedStatus: http.StatusOK},
+		"invalid-token": {authorization: "Bearer invalid", expectedStatus: http.StatusUnauthorized},
+		"missing-token": {authorization: "", expectedStatus: http.StatusUnauthorized},
+		"not-bearer":    {authorization: testToken, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/organization/"+api.OrganizationID(), nil)
+			if testCase.authorization != "" {
+				request.Header.Set("Authorization", testCase.authorization)
+			}
+
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer response.Body.Close()
+
+			if response.StatusCode != testCase.expectedStatus {
+				t.Errorf("expected status %d, got %d", testCase.expectedStatus, response.StatusCode)
+			}
+			if !strings.HasPrefix(response.Header.Get("X-Request-Id"), "fake-") {
+				t.Errorf("expected a request id, got %q", response.Header.Get("X-Request-Id"))
+			}
+		})
+	}
+
+	spheronApi, err := client.NewSpheronApi("invalid", server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	if _, err := spheronApi.GetComputeMachines(context.Background()); err == nil || err.Error() != "Invalid access token" {
+		t.Errorf("expected the client to report the invalid token, got %v", err)
+	}
+}
+
+func TestRemoveRoute(t *testing.T) {
+	api, _, spheronApi := newTestServer(t)
+	api.RemoveRoute("GET /v1/organization/{id}/cluster-instances")
+
+	_, err := spheronApi.GetOrganizationClusterInstances(context.Background(), api.OrganizationID())
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	// Other routes, even sharing a prefix, are still served.
+	if _, err := spheronApi.GetOrganizationClusters(context.Background(), api.OrganizationID()); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if requests := api.RouteRequests("GET /v1/organization/{id}/cluster-instances"); requests != 1 {
+		t.Errorf("expected the removed route to be requested once, got %d", requests)
+	}
+}
+
+func TestUserAgents(t *testing.T) {
+	api, server, _ := newTestServer(t)
+
+	spheronApi, err := client.NewSpheronApi(testToken, server.URL, client.WithUserAgent("test-agent/1.0"))
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	for range 2 {
+		if _, err := spheronApi.GetComputeMachines(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if userAgents := api.UserAgents(); !slices.Equal(userAgents, []string{"test-agent/1.0"}) {
+		t.Errorf("expected a single User-Agent, got %v", userAgents)
+	}
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccClusterDataSource(t *testing.T) {
+	testAccFakeAPI(t)
+
+	config := testAccClusterConfig("web") + `
+data "spherontest_cluster" "by_id" {
+  id = spherontest_cluster.test.id
+}
+
+data "spherontest_cluster" "by_name" {
+  name = spherontest_cluster.test.name
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      `data "spherontest_cluster" "test" {}`,
+				ExpectError: regexp.MustCompile("Missing Attribute Configuration"),
+			},
+			{
+				Config: `
+data "spherontest_cluster" "test" {
+  name = "missing"
+}
+`,
+				ExpectError: regexp.MustCompile("Cluster with name missing not found"),
+			},
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.spherontest_cluster.by_id", "id", "spherontest_cluster.test", "id"),
+					resource.TestCheckResourceAttr("data.spherontest_cluster.by_id", "name", "web"),
+					resource.TestCheckResourceAttrPair("data.spherontest_cluster.by_name", "id", "spherontest_cluster.test", "id"),
+					resource.TestCheckResourceAttr("data.spherontest_cluster.by_name", "name", "web"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,289 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/tracing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var _ resource.Resource = &ClusterResource{}
+var _ resource.ResourceWithImportState = &ClusterResource{}
+var _ resource.ResourceWithUpgradeState = &ClusterResource{}
+
+type ClusterResource struct {
+	client *client.SpheronApi
+}
+
+type ClusterResourceModel struct {
+	ID   types.String `tfsdk:"id"`
+	Name types.String `tfsdk:"name"`
+}
+
+// clusterSchemaVersion is the current schema version of the cluster
+// resource, see UpgradeState for upgrades from prior versions.
+const clusterSchemaVersion = 0
+
+func NewClusterResource() resource.Resource {
+	return &ClusterResource{}
+}
+
+func (r *ClusterResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cluster"
+}
+
+func (r *ClusterResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Cluster resource. Clusters group instances, a cluster can only be destroyed once all of its instances are closed.",
+		Version:             clusterSchemaVersion,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Id of the cluster.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster.",
+				Required:            true,
+			},
+		},
+	}
+}
+
+func (r *ClusterResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerResourceData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *providerResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = data.client
+}
+
+func (r *ClusterResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_cluster", "Create")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
+	var plan ClusterResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	organizationID, err := r.client.GetOrganizationId(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to get organization",
+			err.Error(),
+		)
+		return
+	}
+
+	cluster, err := r.client.CreateCluster(ctx, client.CreateClusterRequest{
+		OrganizationID: organizationID,
+		Name:           plan.Name.ValueString(),
+	})
+	if errors.Is(err, client.ErrNotFound) {
+		resp.Diagnostics.AddError(
+			"Unable to create cluster",
+			"The Spheron API doesn't serve cluster creation. Set cluster_name on instances instead, the cluster is created along with its first instance.",
+		)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to create cluster",
+			err.Error(),
+		)
+		return
+	}
+
+	span.SetAttributes(tracing.AttrClusterID.String(cluster.ID))
+
+	plan.ID = types.StringValue(cluster.ID)
+	plan.Name = types.StringValue(cluster.Name)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	tflog.Debug(ctx, "Created cluster resource", map[string]any{"success": true})
+}
+
+func (r *ClusterResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_cluster", "Read")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
+	if skipReadWithUnknownToken(r.client, &resp.Diagnostics) {
+		return
+	}
+
+	var state ClusterResourceModel
+	tflog.Debug(ctx, "Preparing to read cluster resource")
+
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	span.SetAttributes(tracing.AttrClusterID.String(state.ID.ValueString()))
+
+	if state.ID.ValueString() == "" {
+		resp.Diagnostics.AddError(
+			"Id not provided. Unable to get cluster details.",
+			"Id not provided. Unable to get cluster details.",
+		)
+		return
+	}
+
+	cluster, err := r.client.GetCluster(ctx, state.ID.ValueString())
+	if errors.Is(err, client.ErrNotFound) {
+		resp.State.RemoveResource(ctx)
+		resp.Diagnostics.AddWarning("Cluster not found.", fmt.Sprintf("Cluster %s was deleted outside of Terraform. Applying will create it again.", state.ID.ValueString()))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Coudn't fetch cluster by provided id.",
+			err.Error(),
+		)
+		return
+	}
+
+	state.Name = types.StringValue(cluster.Name)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *ClusterResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_cluster", "Update")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
+	var plan ClusterResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	span.SetAttributes(tracing.AttrClusterID.String(plan.ID.ValueString()))
+
+	cluster, err := r.client.UpdateCluster(ctx, plan.ID.ValueString(), client.UpdateClusterRequest{
+		Name: plan.Name.ValueString(),
+	})
+	if errors.Is(err, client.ErrNotFound) {
+		resp.Diagnostics.AddError(
+			"Unable to rename cluster",
+			fmt.Sprintf("Cluster %s was not found. It was either deleted outside of Terraform, or the Spheron API doesn't serve cluster renames.", plan.ID.ValueString()),
+		)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to rename cluster",
+			err.Error(),
+		)
+		return
+	}
+
+	plan.Name = types.StringValue(cluster.Name)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	tflog.Debug(ctx, "Updated cluster resource", map[string]any{"success": true})
+}
+
+func (r *ClusterResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_cluster", "Delete")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
+	tflog.Debug(ctx, "Preparing to delete cluster resource")
+	var state ClusterResourceModel
+
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	span.SetAttributes(tracing.AttrClusterID.String(state.ID.ValueString()))
+
+	// When the cluster instances can't be listed, the API is left to refuse
+	// deleting a cluster with active instances.
+	instances, err := r.client.GetClusterInstances(ctx, state.ID.ValueString())
+	if errors.Is(err, client.ErrNotFound) {
+		tflog.Warn(ctx, "Cluster instances listing not available, deleting cluster without checking its instances.", map[string]any{"error": err.Error()})
+	} else if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to get cluster instances.",
+			err.Error(),
+		)
+		return
+	}
+
+	for _, instance := range instances {
+		if instance.State != "Closed" {
+			resp.Diagnostics.AddError(
+				"Cluster is not empty",
+				fmt.Sprintf("Cluster %s still has active instance %s. Close all instances of the cluster before destroying it.", state.Name.ValueString(), instance.ID),
+			)
+			return
+		}
+	}
+
+	err = r.client.DeleteCluster(ctx, state.ID.ValueString())
+	if errors.Is(err, client.ErrNotFound) {
+		resp.Diagnostics.AddWarning(
+			"Cluster not deleted.",
+			fmt.Sprintf("Cluster %s was not found, it was either deleted outside of Terraform or the Spheron API doesn't serve cluster deletion. It's removed from state, clusters without instances are left unused.", state.ID.ValueString()),
+		)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to destroy cluster",
+			err.Error(),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Cluster deleted", map[string]any{"success": true})
+}
+
+func (r *ClusterResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func (r *ClusterResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return newStateUpgraders(clusterSchemaVersion, nil)
+}
@@ -0,0 +1,258 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/fakeapi"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// testAccClusterConfig returns a spherontest_cluster.test block named name.
+func testAccClusterConfig(name string) string {
+	return testAccResourceConfig("spherontest_cluster", "test", map[string]string{"name": fmt.Sprintf("%q", name)})
+}
+
+// testAccCheckClusterExists checks whether the cluster of a resource in state
+// exists in the fake API.
+func testAccCheckClusterExists(api *fakeapi.Server, address string, id *string, exists bool) resource.TestCheckFunc {
+	return func(state *terraform.State) error {
+		if address != "" {
+			var err error
+			if *id, err = testAccResourceID(state, address); err != nil {
+				return err
+			}
+		}
+
+		found := slices.ContainsFunc(api.Clusters(), func(cluster client.Cluster) bool { return cluster.ID == *id })
+		if found != exists {
+			return fmt.Errorf("expected cluster %s to exist: %t, got: %t", *id, exists, found)
+		}
+		return nil
+	}
+}
+
+// testAccCreateClusterInstance creates an instance in a cluster outside of
+// Terraform and returns its id.
+func testAccCreateClusterInstance(api *fakeapi.Server, clusterID string) (string, error) {
+	spheronApi, err := testAccClient()
+	if err != nil {
+		return "", err
+	}
+
+	created, err := spheronApi.CreateClusterInstance(context.Background(), client.CreateInstanceRequest{
+		OrganizationID: api.OrganizationID(),
+		UniqueTopicID:  "outside-topic",
+		InstanceName:   "outside",
+		ClusterID:      clusterID,
+		Configuration: client.InstanceConfiguration{
+			Image:                 "nginx",
+			Tag:                   "1.25",
+			InstanceCount:         1,
+			Ports:                 []client.Port{{ContainerPort: 80}},
+			Region:                "us-east",
+			AkashMachineImageName: "Ventus Nano",
+		},
+	})
+	return created.ClusterInstanceID, err
+}
+
+func TestAccClusterResource(t *testing.T) {
+	api := testAccFakeAPI(t)
+	address := "spherontest_cluster.test"
+
+	var clusterID, instanceID string
+
+	instanceConfig := testAccResourceConfig("spherontest_instance", "test", map[string]string{
+		"image":         `"nginx"`,
+		"tag":           `"1.25"`,
+		"cluster_id":    "spherontest_cluster.test.id",
+		"region":        `"us-east"`,
+		"machine_image": `"Ventus Nano"`,
+		"storage":       "10",
+		"replicas":      "1",
+		"ports":         `[{ container_port = 80 }]`,
+	})
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(state *terraform.State) error {
+			return testAccCheckClusterExists(api, "", &clusterID, false)(state)
+		},
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccClusterConfig("web"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionCreate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(address, "id"),
+					resource.TestCheckResourceAttr(address, "name", "web"),
+					testAccCheckClusterExists(api, address, &clusterID, true),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      address,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			// Rename in place
+			{
+				Config: testAccClusterConfig("api"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "name", "api"),
+					testAccCheckResourceIDChanged(address, &clusterID, false),
+					func(*terraform.State) error {
+						for _, cluster := range api.Clusters() {
+							if cluster.ID == clusterID && cluster.Name != "api" {
+								return fmt.Errorf("expected cluster %s to be renamed, got %s", clusterID, cluster.Name)
+							}
+						}
+						return nil
+					},
+				),
+			},
+			// Instances reference the cluster by id.
+			{
+				Config: testAccClusterConfig("api") + instanceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("spherontest_instance.test", "cluster_id", address, "id"),
+					resource.TestCheckResourceAttr("spherontest_instance.test", "cluster_name", "api"),
+					func(state *terraform.State) error {
+						instance, err := testAccFindInstance(api, state, "spherontest_instance.test")
+						if err != nil {
+							return err
+						}
+						if instance.Cluster != clusterID {
+							return fmt.Errorf("expected instance to be deployed to cluster %s, got %s", clusterID, instance.Cluster)
+						}
+						return nil
+					},
+				),
+			},
+			// The cluster isn't deleted while it has active instances.
+			{
+				PreConfig: func() {
+					var err error
+					if instanceID, err = testAccCreateClusterInstance(api, clusterID); err != nil {
+						t.Fatalf("unexpected error creating instance: %s", err)
+					}
+				},
+				Config:      `data "spherontest_organization" "test" {}`,
+				ExpectError: regexp.MustCompile("Cluster is not empty"),
+			},
+			{
+				PreConfig: func() {
+					spheronApi, err := testAccClient()
+					if err != nil {
+						t.Fatalf("unexpected error creating client: %s", err)
+					}
+					if _, err := spheronApi.CloseClusterInstance(context.Background(), instanceID); err != nil {
+						t.Fatalf("unexpected error closing instance: %s", err)
+					}
+				},
+				Config: `data "spherontest_organization" "test" {}`,
+				Check:  testAccCheckClusterExists(api, "", &clusterID, false),
+			},
+		},
+	})
+}
+
+func TestAccClusterResourceDisappears(t *testing.T) {
+	testAccFakeAPI(t)
+	address := "spherontest_cluster.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// A cluster deleted outside of Terraform is created again.
+			{
+				Config: testAccClusterConfig("web"),
+				Check: func(state *terraform.State) error {
+					id, err := testAccResourceID(state, address)
+					if err != nil {
+						return err
+					}
+
+					api, err := testAccClient()
+					if err != nil {
+						return err
+					}
+					return api.DeleteCluster(context.Background(), id)
+				},
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccClusterConfig("web"),
+				Check:  resource.TestCheckResourceAttrSet(address, "id"),
+			},
+		},
+	})
+}
+
+func TestAccClusterResourceWithoutClusterManagement(t *testing.T) {
+	api := testAccFakeAPI(t)
+	address := "spherontest_cluster.test"
+
+	var clusterID string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfig("web"),
+				Check:  testAccCheckClusterExists(api, address, &clusterID, true),
+			},
+			// Clusters the API doesn't delete are removed from state.
+			{
+				PreConfig: func() {
+					api.RemoveRoute("GET /v1/cluster/{id}/instances")
+					api.RemoveRoute("DELETE /v1/cluster/{id}")
+				},
+				Config: `data "spherontest_organization" "test" {}`,
+				Check:  testAccCheckClusterExists(api, "", &clusterID, true),
+			},
+			// Renames and creates fail with an explanation.
+			{
+				PreConfig: func() {
+					api.RemoveRoute("PATCH /v1/cluster/{id}")
+				},
+				ResourceName:       address,
+				ImportState:        true,
+				ImportStateIdFunc:  func(*terraform.State) (string, error) { return clusterID, nil },
+				ImportStatePersist: true,
+				Config:             testAccClusterConfig("web"),
+			},
+			{
+				Config:      testAccClusterConfig("api"),
+				ExpectError: regexp.MustCompile("doesn't serve cluster renames"),
+			},
+			{
+				PreConfig: func() {
+					api.RemoveRoute("POST /v1/cluster")
+				},
+				Config:      testAccClusterConfig("web") + testAccResourceConfig("spherontest_cluster", "other", map[string]string{"name": `"other"`}),
+				ExpectError: regexp.MustCompile("doesn't serve cluster creation"),
+			},
+		},
+	})
+}
@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"terraform-provider-spherontest/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// defaultComputeLimits are used when the limits can't be fetched from the API,
+// or when the API leaves some of them out.
+var defaultComputeLimits = client.ComputeLimits{
+	Cpu:                  []string{"0.5", "1", "2", "4", "8", "16", "32"},
+	Memory:               []string{"0.5", "1", "2", "4", "8", "16", "32"},
+	MaxStorage:           1024,
+	MaxPersistentStorage: 1024,
+}
+
+// getComputeLimits fetches the custom compute spec limits, falling back to
+// defaultComputeLimits for the ones that are not available, or when there is
+// no API client to fetch them with.
+func getComputeLimits(ctx context.Context, api *client.SpheronApi) client.ComputeLimits {
+	if !apiAvailable(api) {
+		return defaultComputeLimits
+	}
+
+	limits, err := api.GetComputeLimits(ctx)
+	if err != nil {
+		tflog.Warn(ctx, "Unable to fetch compute limits, using defaults.", map[string]any{"error": err.Error()})
+		return defaultComputeLimits
+	}
+
+	if len(limits.Cpu) == 0 {
+		limits.Cpu = defaultComputeLimits.Cpu
+	}
+	if len(limits.Memory) == 0 {
+		limits.Memory = defaultComputeLimits.Memory
+	}
+	if limits.MaxStorage <= 0 {
+		limits.MaxStorage = defaultComputeLimits.MaxStorage
+	}
+	if limits.MaxPersistentStorage <= 0 {
+		limits.MaxPersistentStorage = defaultComputeLimits.MaxPersistentStorage
+	}
+
+	return limits
+}
+
+// apiAvailable reports whether API requests can be made with api, which is
+// not the case until the provider is configured or while the token is unknown.
+func apiAvailable(api *client.SpheronApi) bool {
+	return api != nil && api.CredentialsError() == nil
+}
+
+// validateComputeSpecsPlan checks planned compute specs against the limits.
+// cpu and memory are only checked for custom machine images, as named images
+// come with their own specs.
+func validateComputeSpecsPlan(ctx context.Context, limits client.ComputeLimits, machineImage, cpu, memory types.String, storage types.Int64, persistentStorage types.Object) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if !machineImage.IsUnknown() && isCustomMachineImage(machineImage.ValueString()) {
+		diags.Append(validateComputeSpecOption(path.Root("cpu"), "CPU", cpu, limits.Cpu)...)
+		diags.Append(validateComputeSpecOption(path.Root("memory"), "memory", memory, limits.Memory)...)
+	}
+
+	diags.Append(validateStorageLimit(path.Root("storage"), storage, limits.MaxStorage)...)
+
+	if persistentStorage.IsNull() || persistentStorage.IsUnknown() {
+		return diags
+	}
+
+	var storageSpec PersistentStorage
+	diags.Append(persistentStorage.As(ctx, &storageSpec, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return diags
+	}
+
+	diags.Append(validateStorageLimit(path.Root("persistent_storage").AtName("size"), storageSpec.Size, limits.MaxPersistentStorage)...)
+
+	return diags
+}
+
+func validateComputeSpecOption(attributePath path.Path, name string, value types.String, options []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if value.IsNull() || value.IsUnknown() || slices.Contains(options, value.ValueString()) {
+		return diags
+	}
+
+	diags.AddAttributeError(
+		attributePath,
+		fmt.Sprintf("Unsupported %s value.", name),
+		fmt.Sprintf("Value must be one of: %s, got: %s", strings.Join(options, ", "), value.ValueString()),
+	)
+	return diags
+}
+
+func validateStorageLimit(attributePath path.Path, value types.Int64, maxStorage int64) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if value.IsNull() || value.IsUnknown() || value.ValueInt64() <= maxStorage {
+		return diags
+	}
+
+	diags.AddAttributeError(
+		attributePath,
+		"Storage limit exceeded.",
+		fmt.Sprintf("Storage cannot exceed %dGB, got: %dGB", maxStorage, value.ValueInt64()),
+	)
+	return diags
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultProfile is the profile used when none is selected.
+const defaultProfile = "default"
+
+// spheronConfigFile is the config file holding named credential profiles,
+// for example:
+//
+//	profiles:
+//	  default:
+//	    token: <access token>
+//	  staging:
+//	    token: <access token>
+//	    api_url: https://api-staging.example.com
+type spheronConfigFile struct {
+	Profiles map[string]spheronConfigProfile `yaml:"profiles"`
+}
+
+type spheronConfigProfile struct {
+	Token  string `yaml:"token"`
+	ApiUrl string `yaml:"api_url"`
+}
+
+// errProfileNotFound is returned when the selected profile isn't defined in the
+// config file.
+var errProfileNotFound = errors.New("profile not found")
+
+// defaultConfigFilePath returns the path of ~/.spheron/config.
+func defaultConfigFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".spheron", "config"), nil
+}
+
+// loadConfigProfile reads a profile from a config file. A missing file or
+// profile is only an error when it was set explicitly, as the default file
+// and profile are optional.
+func loadConfigProfile(path string, profile string, fileRequired bool, profileRequired bool) (spheronConfigProfile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !fileRequired && !profileRequired {
+			return spheronConfigProfile{}, nil
+		}
+		return spheronConfigProfile{}, fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+
+	var config spheronConfigFile
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return spheronConfigProfile{}, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	values, ok := config.Profiles[profile]
+	if !ok && profileRequired {
+		return spheronConfigProfile{}, fmt.Errorf("%w: %s in config file %s", errProfileNotFound, profile, path)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"terraform-provider-spherontest/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// hoursPerMonth is the average number of hours in a month used for monthly
+// cost estimates.
+const hoursPerMonth = 730
+
+type instanceCostSpec struct {
+	MachineImage      string
+	Cpu               string
+	Memory            string
+	Storage           int64
+	Replicas          int64
+	PersistentStorage *PersistentStorage
+}
+
+// estimateHourlyCost returns the hourly cost in USD of all replicas of an
+// instance with the given specs.
+func estimateHourlyCost(pricing client.ComputePricing, spec instanceCostSpec) (float64, error) {
+	var replicaCost float64
+
+	if isCustomMachineImage(spec.MachineImage) {
+		cpu, err := strconv.ParseFloat(spec.Cpu, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid cpu value: %s", spec.Cpu)
+		}
+
+		memory, err := strconv.ParseFloat(spec.Memory, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid memory value: %s", spec.Memory)
+		}
+
+		replicaCost = cpu*pricing.CpuPerHour + memory*pricing.MemoryPerHour
+	} else {
+		machinePrice, ok := pricing.MachineImages[spec.MachineImage]
+		if !ok {
+			return 0, fmt.Errorf("No price available for machine image: %s", spec.MachineImage)
+		}
+
+		replicaCost = machinePrice
+	}
+
+	replicaCost += float64(spec.Storage) * pricing.StoragePerHour
+
+	if spec.PersistentStorage != nil {
+		class := spec.PersistentStorage.Class.ValueString()
+
+		classPrice, ok := pricing.PersistentStoragePerHour[class]
+		if !ok {
+			return 0, fmt.Errorf("No price available for persistent storage class: %s", class)
+		}
+
+		replicaCost += float64(spec.PersistentStorage.Size.ValueInt64()) * classPrice
+	}
+
+	return replicaCost * float64(spec.Replicas), nil
+}
+
+func roundCost(cost float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(cost*scale) / scale
+}
+
+// modifyPlanCostEstimate sets the estimated cost attributes of an instance
+// plan and warns when an update increases the monthly cost by more than
+// threshold. Estimates are kept as is when the instance doesn't change.
+func modifyPlanCostEstimate(ctx context.Context, api *client.SpheronApi, threshold types.Float64, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if !req.State.Raw.IsNull() && resp.Plan.Raw.Equal(req.State.Raw) {
+		return
+	}
+
+	var machineImage, cpu, memory types.String
+	var storage, replicas types.Int64
+	var persistentStorage types.Object
+
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("machine_image"), &machineImage)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("cpu"), &cpu)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("memory"), &memory)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("storage"), &storage)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("replicas"), &replicas)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("persistent_storage"), &persistentStorage)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	costPerHour := types.Float64Null()
+	costPerMonth := types.Float64Null()
+
+	custom := !machineImage.IsUnknown() && isCustomMachineImage(machineImage.ValueString())
+	specsUnknown := machineImage.IsUnknown() || storage.IsUnknown() || replicas.IsUnknown() || persistentStorage.IsUnknown() ||
+		(custom && (cpu.IsUnknown() || memory.IsUnknown()))
+
+	if specsUnknown {
+		costPerHour = types.Float64Unknown()
+		costPerMonth = types.Float64Unknown()
+	} else if hourly, ok := estimateInstanceCost(ctx, api, &resp.Diagnostics, machineImage, cpu, memory, storage, replicas, persistentStorage); ok {
+		costPerHour = types.Float64Value(roundCost(hourly, 4))
+		costPerMonth = types.Float64Value(roundCost(hourly*hoursPerMonth, 2))
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("estimated_cost_per_hour"), costPerHour)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("estimated_cost_per_month"), costPerMonth)...)
+
+	// Creates have no prior cost to compare with.
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var priorCostPerMonth types.Float64
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("estimated_cost_per_month"), &priorCostPerMonth)...)
+	resp.Diagnostics.Append(costIncreaseDiagnostics(priorCostPerMonth, costPerMonth, threshold)...)
+}
+
+// costIncreaseDiagnostics warns when the monthly cost of an instance update
+// increases by more than threshold. Nothing is compared when either cost isn't
+// known.
+func costIncreaseDiagnostics(priorCostPerMonth, costPerMonth, threshold types.Float64) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if threshold.IsNull() || priorCostPerMonth.IsNull() || priorCostPerMonth.IsUnknown() || costPerMonth.IsNull() || costPerMonth.IsUnknown() {
+		return diags
+	}
+
+	increase := costPerMonth.ValueFloat64() - priorCostPerMonth.ValueFloat64()
+	if increase > threshold.ValueFloat64() {
+		diags.AddAttributeWarning(
+			path.Root("estimated_cost_per_month"),
+			"Estimated cost increase above threshold.",
+			fmt.Sprintf("The estimated monthly cost of the instance increases by $%.2f to $%.2f, which is above the threshold of $%.2f.", increase, costPerMonth.ValueFloat64(), threshold.ValueFloat64()),
+		)
+	}
+
+	return diags
+}
+
+// costEstimatePendingKey marks in private state instances that were imported
+// and not read yet.
+const costEstimatePendingKey = "cost_estimate_pending"
+
+// privateState is implemented by the private state of resource requests and
+// responses.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// markCostEstimatePending makes the first read of an imported instance
+// estimate its cost, as imported instances are read before they are ever
+// planned.
+func markCostEstimatePending(ctx context.Context, private privateState) diag.Diagnostics {
+	return private.SetKey(ctx, costEstimatePendingKey, []byte("true"))
+}
+
+// readCostEstimate returns the estimated costs of instance specs read from
+// the API, for the first read of an imported instance, and the prior
+// estimates otherwise. Estimates are only tried once, nulls are kept when the
+// cost can't be estimated so pricing isn't requested again on every refresh.
+func readCostEstimate(ctx context.Context, api *client.SpheronApi, req resource.ReadRequest, resp *resource.ReadResponse, costPerHour, costPerMonth types.Float64, machineImage, cpu, memory types.String, storage, replicas types.Int64, persistentStorage types.Object) (types.Float64, types.Float64) {
+	pending, diags := req.Private.GetKey(ctx, costEstimatePendingKey)
+	resp.Diagnostics.Append(diags...)
+	if pending == nil {
+		return costPerHour, costPerMonth
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, costEstimatePendingKey, nil)...)
+
+	// Warnings are left out, as for any refresh.
+	var estimateDiags diag.Diagnostics
+	hourly, ok := estimateInstanceCost(ctx, api, &estimateDiags, machineImage, cpu, memory, storage, replicas, persistentStorage)
+	if !ok {
+		return types.Float64Null(), types.Float64Null()
+	}
+
+	return types.Float64Value(roundCost(hourly, 4)), types.Float64Value(roundCost(hourly*hoursPerMonth, 2))
+}
+
+// estimateInstanceCost returns the hourly cost of instance specs. Pricing
+// errors are reported as warnings, as estimates are informational, and no
+// estimate is made without warning when the API doesn't serve pricing.
+func estimateInstanceCost(ctx context.Context, api *client.SpheronApi, diags *diag.Diagnostics, machineImage, cpu, memory types.String, storage, replicas types.Int64, persistentStorage types.Object) (float64, bool) {
+	spec := instanceCostSpec{
+		MachineImage: machineImage.ValueString(),
+		Cpu:          cpu.ValueString(),
+		Memory:       memory.ValueString(),
+		Storage:      storage.ValueInt64(),
+		Replicas:     replicas.ValueInt64(),
+	}
+
+	if !persistentStorage.IsNull() {
+		spec.PersistentStorage = &PersistentStorage{}
+		diags.Append(persistentStorage.As(ctx, spec.PersistentStorage, basetypes.ObjectAsOptions{})...)
+		if diags.HasError() {
+			return 0, false
+		}
+	}
+
+	pricing, err := api.GetComputePricing(ctx)
+	if errors.Is(err, client.ErrNotFound) {
+		tflog.Warn(ctx, "Pricing not available, instance cost is not estimated.", map[string]any{"error": err.Error()})
+		return 0, false
+	}
+	if err != nil {
+		diags.AddWarning(
+			"Unable to estimate instance cost.",
+			err.Error(),
+		)
+		return 0, false
+	}
+
+	hourly, err := estimateHourlyCost(pricing, spec)
+	if err != nil {
+		diags.AddWarning(
+			"Unable to estimate instance cost.",
+			err.Error(),
+		)
+		return 0, false
+	}
+
+	return hourly, true
+}
+
+// resolveUnknownCost replaces an estimate left unknown during plan with null,
+// as computed values have to be known after apply.
+func resolveUnknownCost(cost types.Float64) types.Float64 {
+	if cost.IsUnknown() {
+		return types.Float64Null()
+	}
+	return cost
+}
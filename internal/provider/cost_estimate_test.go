@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/fakeapi"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestEstimateInstanceCost(t *testing.T) {
+	testCases := map[string]struct {
+		machineImage    string
+		handler         func(api *fakeapi.Server) http.Handler
+		expectedHourly  float64
+		expectedOk      bool
+		expectedWarning bool
+	}{
+		"machine-image": {
+			machineImage:   "Ventus Small",
+			expectedHourly: 2 * (0.02 + 10*0.0001),
+			expectedOk:     true,
+		},
+		"unknown-machine-image": {
+			machineImage:    "Ventus Huge",
+			expectedWarning: true,
+		},
+		"pricing-not-found": {
+			machineImage: "Ventus Small",
+			handler: func(api *fakeapi.Server) http.Handler {
+				api.RemoveRoute("GET /v1/compute-machine-image/pricing")
+				return api
+			},
+		},
+		"pricing-failing": {
+			machineImage: "Ventus Small",
+			handler: func(*fakeapi.Server) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				})
+			},
+			expectedWarning: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fake := fakeapi.New("token")
+			var handler http.Handler = fake
+			if testCase.handler != nil {
+				handler = testCase.handler(fake)
+			}
+
+			server := httptest.NewServer(handler)
+			t.Cleanup(server.Close)
+
+			api, err := client.NewSpheronApi("token", server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error creating client: %s", err)
+			}
+
+			var diags diag.Diagnostics
+			hourly, ok := estimateInstanceCost(context.Background(), api, &diags,
+				types.StringValue(testCase.machineImage), types.StringNull(), types.StringNull(),
+				types.Int64Value(10), types.Int64Value(2), types.ObjectNull(getPersistentStorageAtrTypes()))
+
+			if ok != testCase.expectedOk || roundCost(hourly, 6) != roundCost(testCase.expectedHourly, 6) {
+				t.Errorf("expected (%v, %t), got (%v, %t)", testCase.expectedHourly, testCase.expectedOk, hourly, ok)
+			}
+			if diags.HasError() {
+				t.Errorf("unexpected error: %v", diags)
+			}
+			if hasWarning := diags.WarningsCount() > 0; hasWarning != testCase.expectedWarning {
+				t.Errorf("expected warning: %t, got: %v", testCase.expectedWarning, diags)
+			}
+		})
+	}
+}
+
+func TestCostIncreaseDiagnostics(t *testing.T) {
+	testCases := map[string]struct {
+		prior           types.Float64
+		planned         types.Float64
+		threshold       types.Float64
+		expectedWarning bool
+	}{
+		"above-threshold": {
+			prior:           types.Float64Value(10),
+			planned:         types.Float64Value(30),
+			threshold:       types.Float64Value(15),
+			expectedWarning: true,
+		},
+		"below-threshold": {
+			prior:     types.Float64Value(10),
+			planned:   types.Float64Value(20),
+			threshold: types.Float64Value(15),
+		},
+		"decrease": {
+			prior:     types.Float64Value(30),
+			planned:   types.Float64Value(10),
+			threshold: types.Float64Value(0),
+		},
+		"no-threshold": {
+			prior:     types.Float64Value(10),
+			planned:   types.Float64Value(30),
+			threshold: types.Float64Null(),
+		},
+		"no-prior-estimate": {
+			prior:     types.Float64Null(),
+			planned:   types.Float64Value(30),
+			threshold: types.Float64Value(15),
+		},
+		"unknown-estimate": {
+			prior:     types.Float64Value(10),
+			planned:   types.Float64Unknown(),
+			threshold: types.Float64Value(15),
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			diags := costIncreaseDiagnostics(testCase.prior, testCase.planned, testCase.threshold)
+			if hasWarning := diags.WarningsCount() > 0; hasWarning != testCase.expectedWarning {
+				t.Errorf("expected warning: %t, got: %v", testCase.expectedWarning, diags)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -11,6 +12,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 
 	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/tracing"
 
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
@@ -18,6 +20,7 @@ import (
 
 var _ resource.Resource = &DomainResource{}
 var _ resource.ResourceWithImportState = &DomainResource{}
+var _ resource.ResourceWithUpgradeState = &DomainResource{}
 
 type DomainResource struct {
 	client *client.SpheronApi
@@ -32,6 +35,10 @@ type DomainResourceModel struct {
 	InstanceID   types.String `tfsdk:"instance_id"`
 }
 
+// domainSchemaVersion is the current schema version of the domain
+// resource, see UpgradeState for upgrades from prior versions.
+const domainSchemaVersion = 0
+
 func NewDomainResource() resource.Resource {
 	return &DomainResource{}
 }
@@ -43,10 +50,14 @@ func (r *DomainResource) Metadata(ctx context.Context, req resource.MetadataRequ
 func (r *DomainResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Instance domain resource",
+		Version:             domainSchemaVersion,
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				MarkdownDescription: "Id of the domain.",
 				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"name": schema.StringAttribute{
 				MarkdownDescription: "The domain name",
@@ -86,21 +97,24 @@ func (r *DomainResource) Configure(ctx context.Context, req resource.ConfigureRe
 		return
 	}
 
-	client, ok := req.ProviderData.(*client.SpheronApi)
+	data, ok := req.ProviderData.(*providerResourceData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *providerResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = data.client
 }
 
 func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_domain", "Create")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
 	var plan DomainResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -108,12 +122,14 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	span.SetAttributes(tracing.AttrInstanceID.String(plan.InstanceID.ValueString()))
+
 	if !isValidDomainType(plan.Type.ValueString()) {
 		resp.Diagnostics.AddError("DomainType not supported.", "DomainType not supported. Supported domain types are: doain and subdomain.")
 		return
 	}
 
-	instance, err := r.client.GetClusterInstance(plan.InstanceID.ValueString())
+	instance, err := r.client.GetClusterInstance(ctx, plan.InstanceID.ValueString())
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -123,7 +139,7 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	order, err := r.client.GetClusterInstanceOrder(instance.ActiveOrder)
+	order, err := r.client.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -149,7 +165,7 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 		Link: url,
 	}
 
-	domain, err := r.client.AddClusterInstanceDomain(plan.InstanceID.ValueString(), domainRequest)
+	domain, err := r.client.AddClusterInstanceDomain(ctx, plan.InstanceID.ValueString(), domainRequest)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to create domain",
@@ -158,6 +174,8 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	span.SetAttributes(tracing.AttrDomainID.String(domain.ID))
+
 	plan.ID = types.StringValue(domain.ID)
 	plan.Verified = types.BoolValue(domain.Verified)
 
@@ -169,6 +187,13 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 }
 
 func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_domain", "Read")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
+	if skipReadWithUnknownToken(r.client, &resp.Diagnostics) {
+		return
+	}
+
 	var state DomainResourceModel
 	tflog.Debug(ctx, "Preparing to read item resource")
 
@@ -178,6 +203,8 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
+	span.SetAttributes(tracing.AttrDomainID.String(state.ID.ValueString()), tracing.AttrInstanceID.String(state.InstanceID.ValueString()))
+
 	if state.ID.ValueString() == "" || state.InstanceID.ValueString() == "" {
 		resp.Diagnostics.AddError(
 			"Id or instanceId not provided. Unable to get domain details.",
@@ -186,7 +213,7 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
-	domains, err := r.client.GetClusterInstanceDomains(state.InstanceID.ValueString())
+	domains, err := r.client.GetClusterInstanceDomains(ctx, state.InstanceID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Coudn't fetch instance domains for provided instance id.",
@@ -195,7 +222,7 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
-	instance, err := r.client.GetClusterInstance(state.InstanceID.ValueString())
+	instance, err := r.client.GetClusterInstance(ctx, state.InstanceID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Coudn't fetch instance for specified domain.",
@@ -210,7 +237,7 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
-	order, err := r.client.GetClusterInstanceOrder(instance.ActiveOrder)
+	order, err := r.client.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
 	if err != nil {
 		resp.State.RemoveResource(ctx)
 		resp.Diagnostics.AddWarning("Instance domain is attached to doesn't have provisioned deployments.",
@@ -220,6 +247,13 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 	}
 
 	domain, err := findDomainByID(domains, state.ID.ValueString())
+	if err != nil {
+		resp.State.RemoveResource(ctx)
+		resp.Diagnostics.AddWarning("Domain not found.",
+			err.Error(),
+		)
+		return
+	}
 
 	containerPort, err := getPortFromDeploymentURL(order, domain.Link)
 	if err != nil {
@@ -239,6 +273,9 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 }
 
 func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_domain", "Update")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
 	var plan DomainResourceModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -247,12 +284,14 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
+	span.SetAttributes(tracing.AttrDomainID.String(plan.ID.ValueString()), tracing.AttrInstanceID.String(plan.InstanceID.ValueString()))
+
 	if !isValidDomainType(plan.Type.ValueString()) {
 		resp.Diagnostics.AddError("DomainType not supported.", "DomainType not supported. Supported domain types are: doain and subdomain.")
 		return
 	}
 
-	instance, err := r.client.GetClusterInstance(plan.InstanceID.ValueString())
+	instance, err := r.client.GetClusterInstance(ctx, plan.InstanceID.ValueString())
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -262,7 +301,7 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	order, err := r.client.GetClusterInstanceOrder(instance.ActiveOrder)
+	order, err := r.client.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -288,7 +327,7 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		Link: url,
 	}
 
-	domain, err := r.client.UpdateClusterInstanceDomain(plan.InstanceID.ValueString(), plan.ID.ValueString(), domainRequest)
+	domain, err := r.client.UpdateClusterInstanceDomain(ctx, plan.InstanceID.ValueString(), plan.ID.ValueString(), domainRequest)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to create domain",
@@ -308,6 +347,9 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 }
 
 func (r *DomainResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_domain", "Delete")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
 	tflog.Debug(ctx, "Preparing to delete item resource")
 	var state DomainResourceModel
 
@@ -318,7 +360,9 @@ func (r *DomainResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
-	err := r.client.DeleteClusterInstanceDomain(state.InstanceID.ValueString(), state.ID.ValueString())
+	span.SetAttributes(tracing.AttrDomainID.String(state.ID.ValueString()), tracing.AttrInstanceID.String(state.InstanceID.ValueString()))
+
+	err := r.client.DeleteClusterInstanceDomain(ctx, state.InstanceID.ValueString(), state.ID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to destroy Instance",
@@ -331,5 +375,45 @@ func (r *DomainResource) Delete(ctx context.Context, req resource.DeleteRequest,
 }
 
 func (r *DomainResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	split := strings.SplitN(req.ID, "/", 2)
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		resp.Diagnostics.AddError(
+			"Unexpected import identifier",
+			fmt.Sprintf("Expected import identifier with format: <instance_id>/<domain_id> or <instance_id>/<domain_name>. Got: %s", req.ID),
+		)
+		return
+	}
+
+	instanceID, domainIdentifier := split[0], split[1]
+
+	domains, err := r.client.GetClusterInstanceDomains(ctx, instanceID)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Coudn't fetch instance domains for provided instance id.",
+			err.Error(),
+		)
+		return
+	}
+
+	domain, err := findDomainByID(domains, domainIdentifier)
+	if err != nil {
+		domain, err = findDomainByName(domains, domainIdentifier)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to import domain",
+			fmt.Sprintf("Instance %s has no domain with id or name %s.", instanceID, domainIdentifier),
+		)
+		return
+	}
+
+	// instance_port is reverse-mapped from the domain link during Read.
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), domain.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("instance_id"), instanceID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), domain.Name)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("type"), string(domain.Type))...)
+}
+
+func (r *DomainResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return newStateUpgraders(domainSchemaVersion, nil)
 }
@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+// testAccDomainConfig returns an instance with two ports and a
+// spherontest_domain.test block attached to it with the given attributes added
+// to a minimal configuration.
+func testAccDomainConfig(attributes map[string]string) string {
+	config := map[string]string{
+		"name":          `"example.com"`,
+		"type":          `"domain"`,
+		"instance_port": "80",
+		"instance_id":   "spherontest_instance.test.id",
+	}
+	maps.Copy(config, attributes)
+
+	return testAccInstanceConfig(map[string]string{
+		"ports": `[{ container_port = 80 }, { container_port = 443 }]`,
+	}) + testAccResourceConfig("spherontest_domain", "test", config)
+}
+
+// testAccCheckDomainsDeleted checks that the domains in state were deleted,
+// or that their instance was closed along with them.
+func testAccCheckDomainsDeleted(state *terraform.State) error {
+	api, err := testAccClient()
+	if err != nil {
+		return err
+	}
+
+	for address, rs := range state.RootModule().Resources {
+		if rs.Type != "spherontest_domain" {
+			continue
+		}
+
+		instanceID := rs.Primary.Attributes["instance_id"]
+		instance, err := api.GetClusterInstance(context.Background(), instanceID)
+		if err != nil {
+			return err
+		}
+		if instance.State == "Closed" {
+			continue
+		}
+
+		domains, err := api.GetClusterInstanceDomains(context.Background(), instanceID)
+		if err != nil {
+			return err
+		}
+		for _, domain := range domains {
+			if domain.ID == rs.Primary.ID {
+				return fmt.Errorf("%s: domain %s still exists", address, domain.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// testAccDomainImportID returns an import id of the domain in state made of
+// its instance id and the given attribute.
+func testAccDomainImportID(address string, attribute string) resource.ImportStateIdFunc {
+	return func(state *terraform.State) (string, error) {
+		rs, ok := state.RootModule().Resources[address]
+		if !ok {
+			return "", fmt.Errorf("%s not found in state", address)
+		}
+		return rs.Primary.Attributes["instance_id"] + "/" + rs.Primary.Attributes[attribute], nil
+	}
+}
+
+func TestAccDomainResource(t *testing.T) {
+	testAccFakeAPI(t)
+	address := "spherontest_domain.test"
+
+	var domainID string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			// Import blocks are supported from Terraform 1.5.
+			tfversion.SkipBelow(tfversion.Version1_5_0),
+		},
+		CheckDestroy: testAccCheckDomainsDeleted,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccDomainConfig(nil),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("spherontest_instance.test", plancheck.ResourceActionCreate),
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionCreate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(address, "id"),
+					resource.TestCheckResourceAttrPair(address, "instance_id", "spherontest_instance.test", "id"),
+					resource.TestCheckResourceAttr(address, "name", "example.com"),
+					resource.TestCheckResourceAttr(address, "type", "domain"),
+					resource.TestCheckResourceAttr(address, "instance_port", "80"),
+					resource.TestCheckResourceAttr(address, "verified", "false"),
+					testAccCheckResourceID(address, &domainID),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      address,
+				ImportState:       true,
+				ImportStateIdFunc: testAccDomainImportID(address, "id"),
+				ImportStateVerify: true,
+			},
+			// ImportState testing by domain name
+			{
+				ResourceName:      address,
+				ImportState:       true,
+				ImportStateIdFunc: testAccDomainImportID(address, "name"),
+				ImportStateVerify: true,
+			},
+			// Importing with an import block plans clean against the config.
+			{
+				Config:            testAccDomainConfig(nil),
+				ResourceName:      address,
+				ImportState:       true,
+				ImportStateKind:   resource.ImportBlockWithID,
+				ImportStateIdFunc: testAccDomainImportID(address, "name"),
+				ImportPlanChecks: resource.ImportPlanChecks{
+					PreApply: []plancheck.PlanCheck{plancheck.ExpectEmptyPlan()},
+				},
+			},
+			// Update in place
+			{
+				Config: testAccDomainConfig(map[string]string{"instance_port": "443"}),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("spherontest_instance.test", plancheck.ResourceActionNoop),
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "instance_port", "443"),
+					testAccCheckResourceIDChanged(address, &domainID, false),
+				),
+			},
+			// Replace
+			{
+				Config: testAccDomainConfig(map[string]string{"name": `"example.org"`, "instance_port": "443"}),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("spherontest_instance.test", plancheck.ResourceActionNoop),
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionReplace),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "name", "example.org"),
+					testAccCheckResourceIDChanged(address, &domainID, true),
+				),
+			},
+		},
+	})
+}
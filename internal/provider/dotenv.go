@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseDotenv parses the content of a dotenv file into an env map.
+//
+// Supported syntax:
+//   - blank lines and lines starting with # are ignored
+//   - an optional `export ` prefix before the key
+//   - unquoted values are trimmed and end at an inline ` #` comment
+//   - single quoted values are taken literally and may span multiple lines
+//   - double quoted values may span multiple lines and support the \n, \r,
+//     \t, \", \\ and \$ escapes
+//
+// Later definitions of the same key override earlier ones. Variable
+// expansion is not supported.
+func parseDotenv(content string) (map[string]string, error) {
+	envs := map[string]string{}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lineNumber := 0
+
+	for len(content) > 0 {
+		var line string
+		line, content = cutLine(content)
+		lineNumber++
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, rest, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected KEY=value, got %q", lineNumber, line)
+		}
+
+		key = strings.TrimSpace(key)
+		if !envKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid environment variable name %q", lineNumber, key)
+		}
+
+		rest = strings.TrimLeft(rest, " \t")
+		startLine := lineNumber
+
+		var value string
+		switch {
+		case strings.HasPrefix(rest, "'"), strings.HasPrefix(rest, `"`):
+			quote := rest[0]
+			quoted := rest[1:]
+
+			end := findClosingQuote(quoted, quote)
+			for end < 0 {
+				if len(content) == 0 {
+					return nil, fmt.Errorf("line %d: unterminated quoted value for %s", startLine, key)
+				}
+
+				var next string
+				next, content = cutLine(content)
+				lineNumber++
+
+				quoted += "\n" + next
+				end = findClosingQuote(quoted, quote)
+			}
+
+			trailing := strings.TrimSpace(quoted[end+1:])
+			if trailing != "" && !strings.HasPrefix(trailing, "#") {
+				return nil, fmt.Errorf("line %d: unexpected characters after quoted value for %s", lineNumber, key)
+			}
+
+			value = quoted[:end]
+			if quote == '"' {
+				value = unescapeDotenvValue(value)
+			}
+		default:
+			if index := strings.Index(rest, " #"); index >= 0 {
+				rest = rest[:index]
+			}
+			value = strings.TrimSpace(rest)
+		}
+
+		envs[key] = value
+	}
+
+	return envs, nil
+}
+
+func cutLine(content string) (string, string) {
+	line, rest, _ := strings.Cut(content, "\n")
+	return line, rest
+}
+
+// findClosingQuote returns the index of the closing quote in value, skipping
+// escaped quotes in double quoted values, or -1 if there is none.
+func findClosingQuote(value string, quote byte) int {
+	for i := 0; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDotenvValue(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			builder.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		case '"', '\\', '$':
+			builder.WriteByte(value[i])
+		default:
+			builder.WriteByte('\\')
+			builder.WriteByte(value[i])
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,178 @@
+package provider
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	testCases := map[string]struct {
+		content  string
+		expected map[string]string
+	}{
+		"empty": {
+			content:  "",
+			expected: map[string]string{},
+		},
+		"unquoted": {
+			content:  "A=1\nB = two words \n",
+			expected: map[string]string{"A": "1", "B": "two words"},
+		},
+		"empty-value": {
+			content:  "A=\nB=''\nC=\"\"",
+			expected: map[string]string{"A": "", "B": "", "C": ""},
+		},
+		"value-with-equals": {
+			content:  "URL=postgres://host/db?sslmode=disable",
+			expected: map[string]string{"URL": "postgres://host/db?sslmode=disable"},
+		},
+		"comments-and-blank-lines": {
+			content:  "# comment\n\n  # indented comment\nA=1\n",
+			expected: map[string]string{"A": "1"},
+		},
+		"inline-comment": {
+			content:  "A=1 # comment\nB=2#not-a-comment",
+			expected: map[string]string{"A": "1", "B": "2#not-a-comment"},
+		},
+		"hash-inside-quotes": {
+			content:  "A='1 # not a comment' # comment\nB=\"2 # not a comment\" # comment",
+			expected: map[string]string{"A": "1 # not a comment", "B": "2 # not a comment"},
+		},
+		"single-quotes": {
+			content:  `A='literal \n $HOME "quoted"'`,
+			expected: map[string]string{"A": `literal \n $HOME "quoted"`},
+		},
+		"double-quotes": {
+			content:  `A="it's quoted"`,
+			expected: map[string]string{"A": "it's quoted"},
+		},
+		"export-prefix": {
+			content:  "export A=1\nexport   B='2'\nexported=3",
+			expected: map[string]string{"A": "1", "B": "2", "exported": "3"},
+		},
+		"escape-sequences": {
+			content:  `A="line\nbreak\ttab\rreturn \"quote\" back\\slash \$HOME \x"`,
+			expected: map[string]string{"A": "line\nbreak\ttab\rreturn \"quote\" back\\slash $HOME \\x"},
+		},
+		"multi-line-double-quotes": {
+			content:  "KEY=\"-----BEGIN KEY-----\nabc\n-----END KEY-----\"\nNEXT=1",
+			expected: map[string]string{"KEY": "-----BEGIN KEY-----\nabc\n-----END KEY-----", "NEXT": "1"},
+		},
+		"multi-line-single-quotes": {
+			content:  "A='first\nsecond'\n",
+			expected: map[string]string{"A": "first\nsecond"},
+		},
+		"crlf": {
+			content:  "A=1\r\nB=\"two\r\nlines\"\r\n# comment\r\nC='3'\r\n",
+			expected: map[string]string{"A": "1", "B": "two\nlines", "C": "3"},
+		},
+		"later-definition-wins": {
+			content:  "A=1\nA=2",
+			expected: map[string]string{"A": "2"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			envs, err := parseDotenv(testCase.content)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !maps.Equal(envs, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, envs)
+			}
+		})
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	testCases := map[string]struct {
+		content       string
+		expectedError string
+	}{
+		"missing-equals": {
+			content:       "A=1\nINVALID",
+			expectedError: "line 2: expected KEY=value",
+		},
+		"invalid-key": {
+			content:       "1A=1",
+			expectedError: "line 1: invalid environment variable name",
+		},
+		"unterminated-double-quote": {
+			content:       "A=1\nB=\"unterminated\nC=3",
+			expectedError: "line 2: unterminated quoted value for B",
+		},
+		"unterminated-single-quote": {
+			content:       "A='unterminated",
+			expectedError: "line 1: unterminated quoted value for A",
+		},
+		"escaped-closing-quote": {
+			content:       `A="unterminated\"`,
+			expectedError: "unterminated quoted value for A",
+		},
+		"trailing-characters": {
+			content:       `A="quoted" trailing`,
+			expectedError: "line 1: unexpected characters after quoted value for A",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseDotenv(testCase.content)
+			if err == nil {
+				t.Fatal("expected error, got none")
+			}
+
+			if !strings.Contains(err.Error(), testCase.expectedError) {
+				t.Errorf("expected error containing %q, got %q", testCase.expectedError, err.Error())
+			}
+		})
+	}
+}
+
+// formatDotenv renders envs as double quoted dotenv lines.
+func formatDotenv(envs map[string]string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`)
+
+	var content strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(envs)) {
+		content.WriteString(key + `="` + escaper.Replace(envs[key]) + "\"\n")
+	}
+	return content.String()
+}
+
+func FuzzParseDotenv(f *testing.F) {
+	f.Add("A=1\nB = two words \n")
+	f.Add("# comment\n\nexport A=1 # inline\n")
+	f.Add("A='single $quoted'\nB=\"multi\nline\"\n")
+	f.Add(`A="escaped \n \" \\ \$ \x"`)
+	f.Add("A=1\r\nA=2\r\n")
+	f.Add(`A="unterminated\"`)
+	f.Add("1A=1")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		envs, err := parseDotenv(content)
+		if err != nil {
+			return
+		}
+
+		for key := range envs {
+			if !envKeyRegex.MatchString(key) {
+				t.Errorf("expected a valid environment variable name, got %q", key)
+			}
+		}
+
+		// Parsed envs are parsed back from their double quoted form.
+		formatted := formatDotenv(envs)
+		reparsed, err := parseDotenv(formatted)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %s", formatted, err)
+		}
+		if !maps.Equal(reparsed, envs) {
+			t.Errorf("expected %q to parse back to %v, got %v", formatted, envs, reparsed)
+		}
+	})
+}
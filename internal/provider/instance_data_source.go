@@ -0,0 +1,274 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"terraform-provider-spherontest/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/datasourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &InstanceDataSource{}
+var _ datasource.DataSourceWithConfigValidators = &InstanceDataSource{}
+
+func NewInstanceDataSource() datasource.DataSource {
+	return &InstanceDataSource{}
+}
+
+type InstanceDataSource struct {
+	client *client.SpheronApi
+}
+
+type InstanceDataSourceModel struct {
+	Id           types.String `tfsdk:"id"`
+	ClusterName  types.String `tfsdk:"cluster_name"`
+	Name         types.String `tfsdk:"name"`
+	State        types.String `tfsdk:"state"`
+	Image        types.String `tfsdk:"image"`
+	Tag          types.String `tfsdk:"tag"`
+	Region       types.String `tfsdk:"region"`
+	ProviderHost types.String `tfsdk:"provider_host"`
+	MachineImage types.String `tfsdk:"machine_image"`
+	Cpu          types.String `tfsdk:"cpu"`
+	Memory       types.String `tfsdk:"memory"`
+	Storage      types.Int64  `tfsdk:"storage"`
+	Replicas     types.Int64  `tfsdk:"replicas"`
+	Ports        []Port       `tfsdk:"ports"`
+	HealthCheck  types.Object `tfsdk:"health_check"`
+}
+
+func (d *InstanceDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_instance"
+}
+
+func (d *InstanceDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Instance data source. Looks up an existing instance either by `id` or by `cluster_name` and `name`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Id of the instance.",
+				Optional:            true,
+				Computed:            true,
+			},
+			"cluster_name": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster the instance belongs to.",
+				Optional:            true,
+				Computed:            true,
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "The name of the instance. Requires `cluster_name` to be set.",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("cluster_name")),
+				},
+			},
+			"state": schema.StringAttribute{
+				MarkdownDescription: "State of the instance.",
+				Computed:            true,
+			},
+			"image": schema.StringAttribute{
+				MarkdownDescription: "The deployed docker image.",
+				Computed:            true,
+			},
+			"tag": schema.StringAttribute{
+				MarkdownDescription: "The tag of the deployed docker image.",
+				Computed:            true,
+			},
+			"region": schema.StringAttribute{
+				MarkdownDescription: "Region the instance is deployed to.",
+				Computed:            true,
+			},
+			"provider_host": schema.StringAttribute{
+				MarkdownDescription: "Host of the provider the instance is deployed on.",
+				Computed:            true,
+			},
+			"machine_image": schema.StringAttribute{
+				MarkdownDescription: "Machine image used for the instance.",
+				Computed:            true,
+			},
+			"cpu": schema.StringAttribute{
+				MarkdownDescription: "Instance CPU.",
+				Computed:            true,
+			},
+			"memory": schema.StringAttribute{
+				MarkdownDescription: "Instance Memory in GB.",
+				Computed:            true,
+			},
+			"storage": schema.Int64Attribute{
+				MarkdownDescription: "Instance storage in GB.",
+				Computed:            true,
+			},
+			"replicas": schema.Int64Attribute{
+				MarkdownDescription: "Number of instance replicas.",
+				Computed:            true,
+			},
+			"ports": schema.ListNestedAttribute{
+				MarkdownDescription: "The list of port mappings.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"container_port": schema.Int64Attribute{
+							MarkdownDescription: "Container port that is exposed.",
+							Computed:            true,
+						},
+						"exposed_port": schema.Int64Attribute{
+							MarkdownDescription: "The port container port is exposed to.",
+							Computed:            true,
+						},
+						"url": schema.StringAttribute{
+							MarkdownDescription: "URL on which the container port is reachable.",
+							Computed:            true,
+						},
+					},
+				},
+				Computed: true,
+			},
+			"health_check": schema.SingleNestedAttribute{
+				MarkdownDescription: "Health check of the instance.",
+				Attributes: map[string]schema.Attribute{
+					"path": schema.StringAttribute{
+						MarkdownDescription: "Path on which health check is done.",
+						Computed:            true,
+					},
+					"port": schema.Int64Attribute{
+						MarkdownDescription: "Instance container port on which health check is done.",
+						Computed:            true,
+					},
+					"status": schema.StringAttribute{
+						MarkdownDescription: "Latest health check status.",
+						Computed:            true,
+					},
+				},
+				Computed: true,
+			},
+		},
+	}
+}
+
+func (d *InstanceDataSource) ConfigValidators(ctx context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		datasourcevalidator.ExactlyOneOf(
+			path.MatchRoot("id"),
+			path.MatchRoot("name"),
+		),
+	}
+}
+
+func (d *InstanceDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerResourceData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+	d.client = data.client
+}
+
+func (d *InstanceDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	tflog.Debug(ctx, "Preparing to read instance data source.")
+	var state InstanceDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	instanceID := state.Id.ValueString()
+
+	if instanceID == "" {
+		var err error
+		instanceID, err = findInstanceIDByName(ctx, d.client, state.ClusterName.ValueString(), state.Name.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("name"),
+				"Instance not found.",
+				err.Error(),
+			)
+			return
+		}
+	}
+
+	instance, err := d.client.GetClusterInstance(ctx, instanceID)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Coudnt fetch instance by provided id.",
+			err.Error(),
+		)
+		return
+	}
+
+	cluster, err := d.client.GetCluster(ctx, instance.Cluster)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Instance cluster not found.",
+			err.Error(),
+		)
+		return
+	}
+
+	order, err := d.client.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
+	if err == nil {
+		err = checkOrderConfiguration(order)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Instance doesn't have provisioned deployments.",
+			err.Error(),
+		)
+		return
+	}
+
+	state.Id = types.StringValue(instance.ID)
+	state.Name = types.StringValue(instance.Name)
+	state.ClusterName = types.StringValue(cluster.Name)
+	state.State = types.StringValue(instance.State)
+	state.ProviderHost = types.StringValue(getOrderProviderHost(order))
+
+	hcTypes := map[string]attr.Type{
+		"path":   types.StringType,
+		"port":   types.Int64Type,
+		"status": types.StringType,
+	}
+	state.HealthCheck = types.ObjectNull(hcTypes)
+	if instance.HealthCheck.Port != (client.Port{}) {
+		state.HealthCheck = types.ObjectValueMust(hcTypes, map[string]attr.Value{
+			"path":   types.StringValue(instance.HealthCheck.URL),
+			"port":   types.Int64Value(int64(instance.HealthCheck.Port.ContainerPort)),
+			"status": types.StringValue(instance.HealthCheck.Status),
+		})
+	}
+
+	config := order.ClusterInstanceConfiguration
+
+	state.Image = types.StringValue(config.Image)
+	state.Tag = types.StringValue(config.Tag)
+	state.Region = types.StringValue(config.Region)
+	state.MachineImage = types.StringValue(config.AgreedMachineImage.MachineType)
+	state.Cpu = types.StringValue(fmt.Sprint(config.AgreedMachineImage.Cpu))
+	state.Memory = types.StringValue(RemoveGiSuffix(config.AgreedMachineImage.Memory))
+	state.Replicas = types.Int64Value(int64(config.InstanceCount))
+
+	state.Storage = types.Int64Value(parseGiSize(config.AgreedMachineImage.Storage))
+
+	state.Ports = mapModelPortToPort(config.Ports, order)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	tflog.Debug(ctx, "Finished reading instance data source", map[string]any{"success": true})
+}
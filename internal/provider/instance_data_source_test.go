@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccInstanceDataSource(t *testing.T) {
+	testAccFakeAPI(t)
+	address := "spherontest_instance.test"
+
+	config := testAccInstanceConfig(map[string]string{
+		"health_check": testAccInstanceHealthCheck,
+	}) + `
+data "spherontest_instance" "by_id" {
+  id = spherontest_instance.test.id
+}
+
+data "spherontest_instance" "by_name" {
+  cluster_name = spherontest_instance.test.cluster_name
+  name         = spherontest_instance.test.name
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstancesClosed("spherontest_instance"),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.spherontest_instance.by_id", "id", address, "id"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instance.by_id", "name", address, "name"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instance.by_id", "cluster_name", address, "cluster_name"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instance.by_id", "provider_host", address, "provider_host"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "state", "Active"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "image", "nginx"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "tag", "1.25"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "region", "us-east"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "machine_image", "Ventus Nano"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "storage", "10"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "replicas", "1"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "ports.#", "1"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "ports.0.container_port", "80"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instance.by_id", "ports.0.exposed_port", address, "ports.0.exposed_port"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "health_check.path", "/healthz"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_id", "health_check.port", "80"),
+
+					resource.TestCheckResourceAttrPair("data.spherontest_instance.by_name", "id", address, "id"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_name", "cluster_name", "web"),
+					resource.TestCheckResourceAttr("data.spherontest_instance.by_name", "image", "nginx"),
+				),
+			},
+			{
+				Config: config + `
+data "spherontest_instance" "missing" {
+  cluster_name = spherontest_instance.test.cluster_name
+  name         = "missing"
+}
+`,
+				ExpectError: regexp.MustCompile("Instance with name missing not found"),
+			},
+			{
+				Config: config + `
+data "spherontest_instance" "missing" {
+  id = "missing"
+}
+`,
+				ExpectError: regexp.MustCompile("Instance not found"),
+			},
+		},
+	})
+}
+
+func TestAccInstanceDataSourceValidation(t *testing.T) {
+	testAccFakeAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      `data "spherontest_instance" "test" {}`,
+				ExpectError: regexp.MustCompile(`Missing Attribute Configuration`),
+			},
+			{
+				Config: `
+data "spherontest_instance" "test" {
+  id           = "instance"
+  cluster_name = "web"
+  name         = "web"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid Attribute Combination`),
+			},
+			{
+				Config: `
+data "spherontest_instance" "test" {
+  name = "web"
+}
+`,
+				ExpectError: regexp.MustCompile(`Attribute "cluster_name" must be specified when "name" is specified`),
+			},
+		},
+	})
+}
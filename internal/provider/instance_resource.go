@@ -3,13 +3,14 @@ package provider
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
-	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
-	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -22,6 +23,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 
 	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/tracing"
 
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
@@ -30,6 +32,14 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &InstanceResource{}
 var _ resource.ResourceWithImportState = &InstanceResource{}
+var _ resource.ResourceWithConfigValidators = &InstanceResource{}
+var _ resource.ResourceWithValidateConfig = &InstanceResource{}
+var _ resource.ResourceWithUpgradeState = &InstanceResource{}
+var _ resource.ResourceWithModifyPlan = &InstanceResource{}
+
+// instanceSchemaVersion is the current schema version of the instance
+// resource, see UpgradeState for upgrades from prior versions.
+const instanceSchemaVersion = 1
 
 func NewInstanceResource() resource.Resource {
 	return &InstanceResource{}
@@ -37,33 +47,47 @@ func NewInstanceResource() resource.Resource {
 
 // ExampleResource defines the resource implementation.
 type InstanceResource struct {
-	client *client.SpheronApi
+	client                       *client.SpheronApi
+	costIncreaseWarningThreshold types.Float64
 }
 
 // ExampleResourceModel describes the resource data model.
 type InstanceResourceModel struct {
-	Image             types.String `tfsdk:"image"`
-	Tag               types.String `tfsdk:"tag"`
-	ClusterName       types.String `tfsdk:"cluster_name"`
-	Ports             []Port       `tfsdk:"ports"`
-	Env               []Env        `tfsdk:"env"`
-	EnvSecret         []Env        `tfsdk:"env_secret"`
-	Commands          []string     `tfsdk:"commands"`
-	Args              []string     `tfsdk:"args"`
-	Region            types.String `tfsdk:"region"`
-	MachineImage      types.String `tfsdk:"machine_image"`
-	Id                types.String `tfsdk:"id"`
-	HealthCheck       types.Object `tfsdk:"health_check"`
-	Storage           types.Int64  `tfsdk:"storage"`
-	Cpu               types.String `tfsdk:"cpu"`
-	Memory            types.String `tfsdk:"memory"`
-	Replicas          types.Int64  `tfsdk:"replicas"`
-	PersistentStorage types.Object `tfsdk:"persistent_storage"`
+	Name               types.String  `tfsdk:"name"`
+	Image              types.String  `tfsdk:"image"`
+	Tag                types.String  `tfsdk:"tag"`
+	ClusterName        types.String  `tfsdk:"cluster_name"`
+	ClusterId          types.String  `tfsdk:"cluster_id"`
+	Ports              []Port        `tfsdk:"ports"`
+	Env                types.Map     `tfsdk:"env"`
+	EnvSecret          types.Map     `tfsdk:"env_secret"`
+	EnvSecretWo        types.Map     `tfsdk:"env_secret_wo"`
+	EnvSecretWoVersion types.Int64   `tfsdk:"env_secret_wo_version"`
+	EnvFile            types.String  `tfsdk:"env_file"`
+	EnvFileValues      types.Map     `tfsdk:"env_file_values"`
+	Commands           []string      `tfsdk:"commands"`
+	Args               []string      `tfsdk:"args"`
+	Region             types.String  `tfsdk:"region"`
+	MachineImage       types.String  `tfsdk:"machine_image"`
+	Id                 types.String  `tfsdk:"id"`
+	HealthCheck        types.Object  `tfsdk:"health_check"`
+	Storage            types.Int64   `tfsdk:"storage"`
+	Cpu                types.String  `tfsdk:"cpu"`
+	Memory             types.String  `tfsdk:"memory"`
+	Replicas           types.Int64   `tfsdk:"replicas"`
+	PersistentStorage  types.Object  `tfsdk:"persistent_storage"`
+	Url                types.String  `tfsdk:"url"`
+	ProviderHost       types.String  `tfsdk:"provider_host"`
+	State              types.String  `tfsdk:"state"`
+	CreatedAt          types.String  `tfsdk:"created_at"`
+	EstimatedCostHour  types.Float64 `tfsdk:"estimated_cost_per_hour"`
+	EstimatedCostMonth types.Float64 `tfsdk:"estimated_cost_per_month"`
 }
 
 type Port struct {
-	ContainerPort types.Int64 `tfsdk:"container_port"`
-	ExposedPort   types.Int64 `tfsdk:"exposed_port"`
+	ContainerPort types.Int64  `tfsdk:"container_port"`
+	ExposedPort   types.Int64  `tfsdk:"exposed_port"`
+	URL           types.String `tfsdk:"url"`
 }
 
 type Env struct {
@@ -90,8 +114,18 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 	resp.Schema = schema.Schema{
 		// This description is used by the documentation generator and the language server.
 		MarkdownDescription: "Instnce resource",
+		Version:             instanceSchemaVersion,
 
 		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "The name of the instance. Generated by Spheron if left empty.",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
 			"image": schema.StringAttribute{
 				MarkdownDescription: "The docker image to deploy. Currently only public dockerhub images are supported.",
 				Required:            true,
@@ -104,14 +138,26 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 				Required:            true,
 			},
 			"cluster_name": schema.StringAttribute{
-				MarkdownDescription: "The name of the cluster.",
-				Required:            true,
+				MarkdownDescription: "The name of the cluster. Cluster with this name is created if it doesn't exist. When set together with `cluster_id` it must match the name of that cluster.",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cluster_id": schema.StringAttribute{
+				MarkdownDescription: "The id of an existing cluster, for example `spherontest_cluster.example.id`.",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"storage": schema.Int64Attribute{
-				MarkdownDescription: "Instance storage in GB. Value cannot exceed 1024GB",
+				MarkdownDescription: "Instance storage in GB. The maximum is defined by Spheron and checked at plan time.",
 				Validators: []validator.Int64{
 					int64validator.AtLeast(1),
-					int64validator.AtMost(1024),
 				},
 				Required: true,
 				PlanModifiers: []planmodifier.Int64{
@@ -119,7 +165,7 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 				},
 			},
 			"cpu": schema.StringAttribute{
-				MarkdownDescription: "Instance CPU. Value cannot exceed 1024GB",
+				MarkdownDescription: "Instance CPU. When set together with a named `machine_image` it must match the CPU of that image.",
 				Optional:            true,
 				Computed:            true,
 				PlanModifiers: []planmodifier.String{
@@ -127,21 +173,11 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 					stringplanmodifier.UseStateForUnknown(),
 				},
 				Validators: []validator.String{
-					stringvalidator.OneOf(
-						"0.5",
-						"1",
-						"2",
-						"4",
-						"8",
-						"16",
-						"32",
-					),
 					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("memory")),
-					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("machine_image")),
 				},
 			},
 			"memory": schema.StringAttribute{
-				MarkdownDescription: "Instance Memory in GB.",
+				MarkdownDescription: "Instance Memory in GB. When set together with a named `machine_image` it must match the memory of that image.",
 				Optional:            true,
 				Computed:            true,
 				PlanModifiers: []planmodifier.String{
@@ -149,17 +185,7 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 					stringplanmodifier.UseStateForUnknown(),
 				},
 				Validators: []validator.String{
-					stringvalidator.OneOf(
-						"0.5",
-						"1",
-						"2",
-						"4",
-						"8",
-						"16",
-						"32",
-					),
 					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("cpu")),
-					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("machine_image")),
 				},
 			},
 			"replicas": schema.Int64Attribute{
@@ -189,6 +215,13 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 								int64planmodifier.UseStateForUnknown(),
 							},
 						},
+						"url": schema.StringAttribute{
+							MarkdownDescription: "URL on which the container port is reachable. Known after the deployment.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
 					},
 				},
 				Required: true,
@@ -196,37 +229,49 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 					listplanmodifier.RequiresReplace(),
 				},
 			},
-			"env": schema.SetNestedAttribute{
-				MarkdownDescription: "The list of environmetnt variables.",
-				NestedObject: schema.NestedAttributeObject{
-					Attributes: map[string]schema.Attribute{
-						"key": schema.StringAttribute{
-							MarkdownDescription: "Environment variable key.",
-							Required:            true,
-						},
-						"value": schema.StringAttribute{
-							MarkdownDescription: "Environment variable value.",
-							Required:            true,
-						},
-					},
+			"env": schema.MapAttribute{
+				MarkdownDescription: "Environment variables of the instance keyed by name.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(envKeyValidator()),
 				},
-				Optional: true,
 			},
-			"env_secret": schema.SetNestedAttribute{
-				MarkdownDescription: "The list of secret environmetnt variables.",
-				NestedObject: schema.NestedAttributeObject{
-					Attributes: map[string]schema.Attribute{
-						"key": schema.StringAttribute{
-							MarkdownDescription: "Environment variable key.",
-							Required:            true,
-						},
-						"value": schema.StringAttribute{
-							MarkdownDescription: "Environment variable value.",
-							Required:            true,
-						},
-					},
+			"env_secret": schema.MapAttribute{
+				MarkdownDescription: "Secret environment variables of the instance keyed by name. Values are stored in state, use `env_secret_wo` to keep them out of it.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(envKeyValidator()),
+				},
+			},
+			"env_secret_wo": schema.MapAttribute{
+				MarkdownDescription: "Secret environment variables which are never stored in state. Requires Terraform 1.11 or later. Change `env_secret_wo_version` to apply updated values.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Sensitive:           true,
+				WriteOnly:           true,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(envKeyValidator()),
+				},
+			},
+			"env_secret_wo_version": schema.Int64Attribute{
+				MarkdownDescription: "Version of `env_secret_wo` values. Changing it updates the instance with the current `env_secret_wo` values.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AlsoRequires(path.MatchRelative().AtParent().AtName("env_secret_wo")),
 				},
-				Optional: true,
+			},
+			"env_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a dotenv file with environment variables of the instance. The file is read at plan time and variables from `env` take precedence over the ones from the file.",
+				Optional:            true,
+			},
+			"env_file_values": schema.MapAttribute{
+				MarkdownDescription: "Environment variables loaded from `env_file`. Marked sensitive as env files often hold credentials.",
+				ElementType:         types.StringType,
+				Computed:            true,
+				Sensitive:           true,
 			},
 			"commands": schema.ListAttribute{
 				MarkdownDescription: "List of executables for docker CMD command.",
@@ -246,17 +291,13 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 				},
 			},
 			"machine_image": schema.StringAttribute{
-				MarkdownDescription: "Machine image name which should be used for deploying instance.",
+				MarkdownDescription: "Machine image name which should be used for deploying instance. Use `Custom Plan` or leave empty to deploy with custom `cpu` and `memory`.",
 				Optional:            true,
 				Computed:            true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 					stringplanmodifier.UseStateForUnknown(),
 				},
-				Validators: []validator.String{
-					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("memory")),
-					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("cpu")),
-				},
 			},
 			"health_check": schema.SingleNestedAttribute{
 				MarkdownDescription: "Path and container port on which health check should be done.",
@@ -299,11 +340,10 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 						},
 					},
 					"size": schema.Int64Attribute{
-						MarkdownDescription: "Persistent storage in GB. Value cannot exceed 1024GB",
+						MarkdownDescription: "Persistent storage in GB. The maximum is defined by Spheron and checked at plan time.",
 						Required:            true,
 						Validators: []validator.Int64{
 							int64validator.AtLeast(1),
-							int64validator.AtMost(1024),
 						},
 						PlanModifiers: []planmodifier.Int64{
 							int64planmodifier.RequiresReplace(),
@@ -323,31 +363,165 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"url": schema.StringAttribute{
+				MarkdownDescription: "Latest preview URL of the instance.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"provider_host": schema.StringAttribute{
+				MarkdownDescription: "Host of the provider the instance is deployed on.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"state": schema.StringAttribute{
+				MarkdownDescription: "State of the instance.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"created_at": schema.StringAttribute{
+				MarkdownDescription: "Creation time of the instance in RFC3339 format.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"estimated_cost_per_hour": schema.Float64Attribute{
+				MarkdownDescription: "Estimated hourly cost of all instance replicas in USD, computed during plan from Spheron pricing.",
+				Computed:            true,
+			},
+			"estimated_cost_per_month": schema.Float64Attribute{
+				MarkdownDescription: "Estimated monthly cost of all instance replicas in USD, based on 730 hours per month.",
+				Computed:            true,
+			},
 		},
 	}
 }
 
+func (r *InstanceResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.AtLeastOneOf(
+			path.MatchRoot("cluster_name"),
+			path.MatchRoot("cluster_id"),
+		),
+	}
+}
+
+func (r *InstanceResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var envs, secretEnvs, writeOnlyEnvs types.Map
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("env"), &envs)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("env_secret"), &secretEnvs)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("env_secret_wo"), &writeOnlyEnvs)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateUniqueEnvKeys(map[string]types.Map{
+		"env":           envs,
+		"env_secret":    secretEnvs,
+		"env_secret_wo": writeOnlyEnvs,
+	})...)
+}
+
+func (r *InstanceResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan when the resource is being destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var envFile types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("env_file"), &envFile)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	envFileValues, diags := loadEnvFileValues(ctx, envFile, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("env_file_values"), envFileValues)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	machineImage, machineImageChanged, diags := getPlannedChange[types.String](ctx, req.Plan, req.State, path.Root("machine_image"))
+	resp.Diagnostics.Append(diags...)
+	cpu, cpuChanged, diags := getPlannedChange[types.String](ctx, req.Plan, req.State, path.Root("cpu"))
+	resp.Diagnostics.Append(diags...)
+	memory, memoryChanged, diags := getPlannedChange[types.String](ctx, req.Plan, req.State, path.Root("memory"))
+	resp.Diagnostics.Append(diags...)
+	region, regionChanged, diags := getPlannedChange[types.String](ctx, req.Plan, req.State, path.Root("region"))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Catalog checks need the API client, which is not available until the
+	// provider is configured, and can't be done while the token is unknown.
+	// Compute specs are then checked against the default limits.
+	clientAvailable := apiAvailable(r.client)
+
+	if clientAvailable && (machineImageChanged || cpuChanged || memoryChanged) {
+		resp.Diagnostics.Append(validateMachineImagePlan(ctx, r.client, machineImage, cpu, memory)...)
+	}
+
+	if clientAvailable && regionChanged {
+		resp.Diagnostics.Append(validateRegionPlan(ctx, r.client, region)...)
+	}
+
+	storage, storageChanged, diags := getPlannedChange[types.Int64](ctx, req.Plan, req.State, path.Root("storage"))
+	resp.Diagnostics.Append(diags...)
+	persistentStorage, persistentStorageChanged, diags := getPlannedChange[types.Object](ctx, req.Plan, req.State, path.Root("persistent_storage"))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if machineImageChanged || cpuChanged || memoryChanged || storageChanged || persistentStorageChanged {
+		limits := getComputeLimits(ctx, r.client)
+		resp.Diagnostics.Append(validateComputeSpecsPlan(ctx, limits, machineImage, cpu, memory, storage, persistentStorage)...)
+	}
+
+	if resp.Diagnostics.HasError() || !clientAvailable {
+		return
+	}
+
+	modifyPlanCostEstimate(ctx, r.client, r.costIncreaseWarningThreshold, req, resp)
+}
+
 func (r *InstanceResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*client.SpheronApi)
+	data, ok := req.ProviderData.(*providerResourceData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *providerResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = data.client
+	r.costIncreaseWarningThreshold = data.costIncreaseWarningThreshold
 }
 
 func (r *InstanceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_instance", "Create")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
 	var plan InstanceResourceModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -356,7 +530,7 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	organization, err := r.client.GetOrganization()
+	organization, err := r.client.GetOrganization(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to get organization",
@@ -384,6 +558,35 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		}
 	}
 
+	if !plan.ClusterId.IsNull() && !plan.ClusterId.IsUnknown() {
+		cluster, err := r.client.GetCluster(ctx, plan.ClusterId.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("cluster_id"),
+				"Instance cluster not found.",
+				err.Error(),
+			)
+			return
+		}
+
+		if !plan.ClusterName.IsUnknown() && plan.ClusterName.ValueString() != "" && plan.ClusterName.ValueString() != cluster.Name {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("cluster_name"),
+				"Cluster name doesn't match cluster_id.",
+				fmt.Sprintf("Cluster %s is named %s, got: %s", cluster.ID, cluster.Name, plan.ClusterName.ValueString()),
+			)
+			return
+		}
+
+		plan.ClusterName = types.StringValue(cluster.Name)
+	}
+
+	envs, secretEnvs, diags := getInstanceEnvMaps(ctx, plan, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	topicId := uuid.New()
 
 	instanceConfig := client.InstanceConfiguration{
@@ -394,18 +597,48 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		InstanceCount: int(plan.Replicas.ValueInt64()),
 		BuildImage:    false,
 		Ports:         mapPortToPortModel(plan.Ports),
-		Env:           append(mapEnvsToClientEnvs(plan.Env, false), mapEnvsToClientEnvs(plan.EnvSecret, true)...),
+		Env:           append(mapEnvMapToClientEnvs(envs, false), mapEnvMapToClientEnvs(secretEnvs, true)...),
 		Command:       plan.Commands,
 		Args:          plan.Args,
 		Region:        plan.Region.ValueString(),
 	}
 
-	if plan.MachineImage.ValueString() == "" {
+	if isCustomMachineImage(plan.MachineImage.ValueString()) {
 		customSpecs.CPU = plan.Cpu.ValueString()
 		customSpecs.Memory = fmt.Sprintf("%sGi", plan.Memory.ValueString())
 
-		plan.MachineImage = types.StringValue("Custom Plan")
+		plan.MachineImage = types.StringValue(customMachineImage)
 	} else {
+		if plan.Cpu.ValueString() != "" || plan.Memory.ValueString() != "" {
+			computeMachines, err := r.client.GetComputeMachines(ctx)
+			if err != nil {
+				resp.Diagnostics.AddError(
+					"Unable to get fetch available compute machines.",
+					err.Error(),
+				)
+				return
+			}
+
+			machine, err := findComputeMachine(computeMachines, plan.MachineImage.ValueString())
+			if err != nil {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("machine_image"),
+					"Unable to get machine image by provided name.",
+					err.Error(),
+				)
+				return
+			}
+
+			if err := checkComputeMachineSpecs(machine, plan.Cpu.ValueString(), plan.Memory.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("machine_image"),
+					"Machine image doesn't match provided cpu and memory.",
+					err.Error(),
+				)
+				return
+			}
+		}
+
 		instanceConfig.AkashMachineImageName = plan.MachineImage.ValueString()
 	}
 
@@ -415,9 +648,11 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		OrganizationID:  organization.ID,
 		UniqueTopicID:   topicId.String(),
 		Configuration:   instanceConfig,
+		InstanceName:    plan.Name.ValueString(),
 		ClusterURL:      plan.Image.ValueString(),
 		ClusterProvider: "DOCKERHUB",
 		ClusterName:     plan.ClusterName.ValueString(),
+		ClusterID:       plan.ClusterId.ValueString(),
 	}
 
 	if !plan.HealthCheck.IsNull() {
@@ -428,7 +663,7 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		createRequest.HealthCheckPort = healthCheck.Port.String()
 	}
 
-	response, err := r.client.CreateClusterInstance(createRequest)
+	response, err := r.client.CreateClusterInstance(ctx, createRequest)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -438,6 +673,12 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
+	span.SetAttributes(
+		tracing.AttrClusterID.String(response.ClusterID),
+		tracing.AttrInstanceID.String(response.ClusterInstanceID),
+		tracing.AttrOrderID.String(response.ClusterInstanceOrderID),
+	)
+
 	eventDataString, err := r.client.WaitForDeployedEvent(ctx, topicId.String())
 
 	if err != nil {
@@ -457,23 +698,44 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	if plan.Cpu.ValueString() == "" || plan.Memory.ValueString() == "" {
-		order, err := r.client.GetClusterInstanceOrder(response.ClusterInstanceOrderID)
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Instance doesn't have provisioned deployments.",
-				err.Error(),
-			)
-			return
-		}
+	order, err := r.client.GetClusterInstanceOrder(ctx, response.ClusterInstanceOrderID)
+	if err == nil {
+		err = checkOrderConfiguration(order)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Instance doesn't have provisioned deployments.",
+			err.Error(),
+		)
+		return
+	}
+
+	instance, err := r.client.GetClusterInstance(ctx, response.ClusterInstanceID)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Coudnt fetch instance by provided id.",
+			err.Error(),
+		)
+		return
+	}
 
+	if plan.Cpu.ValueString() == "" || plan.Memory.ValueString() == "" {
 		plan.Memory = types.StringValue(RemoveGiSuffix(order.ClusterInstanceConfiguration.AgreedMachineImage.Memory))
 		plan.Cpu = types.StringValue(fmt.Sprint(order.ClusterInstanceConfiguration.AgreedMachineImage.Cpu))
 	}
 
 	// Map response body to model
 	plan.Id = types.StringValue(response.ClusterInstanceID)
-	plan.Ports = mapModelPortToPort(ports)
+	plan.Name = types.StringValue(instance.Name)
+	plan.ClusterId = types.StringValue(response.ClusterID)
+	plan.Ports = mapModelPortToPort(ports, order)
+	plan.Url = types.StringValue(instance.LatestURLPreview)
+	plan.ProviderHost = types.StringValue(getOrderProviderHost(order))
+	plan.State = types.StringValue(instance.State)
+	plan.CreatedAt = types.StringValue(formatInstanceCreatedAt(instance))
+
+	plan.EstimatedCostHour = resolveUnknownCost(plan.EstimatedCostHour)
+	plan.EstimatedCostMonth = resolveUnknownCost(plan.EstimatedCostMonth)
 
 	// Set state to fully populated data
 	diags = resp.State.Set(ctx, plan)
@@ -486,6 +748,13 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 }
 
 func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_instance", "Read")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
+	if skipReadWithUnknownToken(r.client, &resp.Diagnostics) {
+		return
+	}
+
 	var state InstanceResourceModel
 	tflog.Debug(ctx, "Preparing to read item resource")
 	// Get current state
@@ -495,6 +764,8 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	span.SetAttributes(tracing.AttrInstanceID.String(state.Id.ValueString()))
+
 	if state.Id.ValueString() == "" {
 		resp.Diagnostics.AddError(
 			"Id not provided. Unable to get instance details.",
@@ -503,7 +774,7 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
-	instance, err := r.client.GetClusterInstance(state.Id.ValueString())
+	instance, err := r.client.GetClusterInstance(ctx, state.Id.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Coudnt fetch instance by provided id.",
@@ -518,7 +789,10 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
-	order, err := r.client.GetClusterInstanceOrder(instance.ActiveOrder)
+	order, err := r.client.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
+	if err == nil {
+		err = checkOrderConfiguration(order)
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Instance doesn't have provisioned deployments.",
@@ -527,7 +801,7 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
-	cluster, err := r.client.GetCluster(instance.Cluster)
+	cluster, err := r.client.GetCluster(ctx, instance.Cluster)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Instance cluster not found.",
@@ -536,76 +810,67 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
-	state.Args = order.ClusterInstanceConfiguration.Args
+	state.Name = types.StringValue(instance.Name)
+	state.Args = normalizeStringList(order.ClusterInstanceConfiguration.Args)
 	state.ClusterName = types.StringValue(cluster.Name)
-	state.Commands = order.ClusterInstanceConfiguration.Command
-	state.Env = mapClientEnvsToEnvs(order.ClusterInstanceConfiguration.Env, false)
-	state.EnvSecret = mapClientEnvsToEnvs(order.ClusterInstanceConfiguration.Env, true)
+	state.ClusterId = types.StringValue(cluster.ID)
+	state.Commands = normalizeStringList(order.ClusterInstanceConfiguration.Command)
+	priorSecretEnvs, diags := mapMapValueToEnvMap(ctx, state.EnvSecret)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretEnvs := mergeSecretEnvs(mapClientEnvsToEnvMap(order.ClusterInstanceConfiguration.Env, true), priorSecretEnvs, !state.EnvSecretWoVersion.IsNull())
+	envs, envFileValues, diags := splitEnvFileValues(ctx, mapClientEnvsToEnvMap(order.ClusterInstanceConfiguration.Env, false), state.Env, state.EnvFileValues)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Env = mapEnvMapToMapValue(envs, state.Env)
+	state.EnvFileValues = envFileValues
+	state.EnvSecret = mapEnvMapToMapValue(secretEnvs, state.EnvSecret)
 	state.Image = types.StringValue(order.ClusterInstanceConfiguration.Image)
 	state.MachineImage = types.StringValue(order.ClusterInstanceConfiguration.AgreedMachineImage.MachineType)
-	state.Ports = mapModelPortToPort(order.ClusterInstanceConfiguration.Ports)
+	state.Ports = mapModelPortToPort(order.ClusterInstanceConfiguration.Ports, order)
 	state.Region = types.StringValue(order.ClusterInstanceConfiguration.Region)
 	state.Tag = types.StringValue(order.ClusterInstanceConfiguration.Tag)
 	state.Replicas = types.Int64Value(int64(order.ClusterInstanceConfiguration.InstanceCount))
+	state.Url = types.StringValue(instance.LatestURLPreview)
+	state.ProviderHost = types.StringValue(getOrderProviderHost(order))
+	state.State = types.StringValue(instance.State)
+	state.CreatedAt = types.StringValue(formatInstanceCreatedAt(instance))
 
-	numberStr := RemoveGiSuffix(order.ClusterInstanceConfiguration.AgreedMachineImage.Storage) // Remove the last two characters ("Gi")
-	number, _ := strconv.Atoi(numberStr)
-	state.Storage = types.Int64Value(int64(number))
-
+	state.Storage = types.Int64Value(parseGiSize(order.ClusterInstanceConfiguration.AgreedMachineImage.Storage))
 	state.Memory = types.StringValue(RemoveGiSuffix(order.ClusterInstanceConfiguration.AgreedMachineImage.Memory))
 	state.Cpu = types.StringValue(fmt.Sprint(order.ClusterInstanceConfiguration.AgreedMachineImage.Cpu))
+	state.HealthCheck = mapClientHealthCheckToObject(instance.HealthCheck)
+	state.PersistentStorage = mapClientPersistentStorageToObject(order.ClusterInstanceConfiguration.AgreedMachineImage.PersistentStorage)
 
-	if instance.HealthCheck.Port != (client.Port{}) {
-		hcTypes := make(map[string]attr.Type)
-		hcValues := make(map[string]attr.Value)
-
-		hcTypes["port"] = types.Int64Type
-		hcTypes["path"] = types.StringType
-
-		hcValues["port"] = types.Int64Value(int64(instance.HealthCheck.Port.ContainerPort))
-		hcValues["path"] = types.StringValue(instance.HealthCheck.URL)
-
-		state.HealthCheck = types.ObjectValueMust(hcTypes, hcValues)
-	}
-
-	if order.ClusterInstanceConfiguration.AgreedMachineImage.PersistentStorage != nil &&
-		order.ClusterInstanceConfiguration.AgreedMachineImage.PersistentStorage.Class != "" {
-		var pStorage = order.ClusterInstanceConfiguration.AgreedMachineImage.PersistentStorage
-
-		psTypes := make(map[string]attr.Type)
-		psValues := make(map[string]attr.Value)
-
-		psTypes["class"] = types.StringType
-		psTypes["mount_point"] = types.StringType
-		psTypes["size"] = types.Int64Type
-
-		value, _ := GetStorageClassFromValue(pStorage.Class)
-
-		psValues["class"] = types.StringValue(value)
-		psValues["mount_point"] = types.StringValue(pStorage.MountPoint)
-
-		numberStr := pStorage.Size[:len(pStorage.Size)-2] // Remove the last two characters ("Gi")
-		number, _ := strconv.Atoi(numberStr)
-		psValues["size"] = types.Int64Value(int64(number))
-
-		state.PersistentStorage = types.ObjectValueMust(psTypes, psValues)
-	}
+	state.EstimatedCostHour, state.EstimatedCostMonth = readCostEstimate(ctx, r.client, req, resp, state.EstimatedCostHour, state.EstimatedCostMonth, state.MachineImage, state.Cpu, state.Memory, state.Storage, state.Replicas, state.PersistentStorage)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
 
 func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var plan InstanceResourceModel
+	ctx, span := startResourceSpan(ctx, "spherontest_instance", "Update")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
+	var plan, state InstanceResourceModel
 
-	// Retrieve values from plan
+	// Retrieve values from plan and prior state
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	organization, err := r.client.GetOrganization()
+	span.SetAttributes(tracing.AttrInstanceID.String(plan.Id.ValueString()))
+
+	organization, err := r.client.GetOrganization(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to get organization",
@@ -623,7 +888,7 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 		HealthCheckPort: int(healthCheck.Port.ValueInt64()),
 	}
 
-	_, err = r.client.UpdateClusterInstanceHealthCheckInfo(plan.Id.ValueString(), hcUpdate)
+	_, err = r.client.UpdateClusterInstanceHealthCheckInfo(ctx, plan.Id.ValueString(), hcUpdate)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -633,7 +898,7 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	instance, err := r.client.GetClusterInstance(plan.Id.ValueString())
+	instance, err := r.client.GetClusterInstance(ctx, plan.Id.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Coudnt fetch instance by provided id.",
@@ -642,7 +907,10 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	order, err := r.client.GetClusterInstanceOrder(instance.ActiveOrder)
+	order, err := r.client.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
+	if err == nil {
+		err = checkOrderConfiguration(order)
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Instance doesn't have provisioned deployments.",
@@ -651,11 +919,28 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	envs := append(mapEnvsToClientEnvs(plan.Env, false), mapEnvsToClientEnvs(plan.EnvSecret, true)...)
+	planEnvs, planSecretEnvs, diags := getInstanceEnvMaps(ctx, plan, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	envs := append(mapEnvMapToClientEnvs(planEnvs, false), mapEnvMapToClientEnvs(planSecretEnvs, true)...)
 
 	argsEqual := reflect.DeepEqual(order.ClusterInstanceConfiguration.Args, plan.Args)
 	commandEqual := reflect.DeepEqual(order.ClusterInstanceConfiguration.Command, plan.Commands)
-	envEqual := reflect.DeepEqual(envs, order.ClusterInstanceConfiguration.Env)
+	// Masked secret values are filled in from the prior state rather than
+	// the plan, so that changed secrets are detected.
+	priorSecretEnvs, diags := mapMapValueToEnvMap(ctx, state.EnvSecret)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretEnvs := mergeSecretEnvs(mapClientEnvsToEnvMap(order.ClusterInstanceConfiguration.Env, true), priorSecretEnvs, false)
+	envEqual := maps.Equal(planEnvs, mapClientEnvsToEnvMap(order.ClusterInstanceConfiguration.Env, false)) &&
+		secretEnvsEqual(planSecretEnvs, secretEnvs) &&
+		plan.EnvSecretWoVersion.Equal(state.EnvSecretWoVersion)
 	tagEqual := plan.Tag.ValueString() == order.ClusterInstanceConfiguration.Tag
 
 	if !argsEqual || !commandEqual || !envEqual || !tagEqual {
@@ -670,7 +955,7 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 			OrganizationID: organization.ID,
 		}
 
-		_, err = r.client.UpdateClusterInstance(plan.Id.ValueString(), updateRequest)
+		response, err := r.client.UpdateClusterInstance(ctx, plan.Id.ValueString(), updateRequest)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Unable to update instance.",
@@ -679,6 +964,8 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 			return
 		}
 
+		span.SetAttributes(tracing.AttrOrderID.String(response.ClusterInstanceOrderID))
+
 		_, err = r.client.WaitForDeployedEvent(ctx, topicId.String())
 
 		if err != nil {
@@ -690,6 +977,9 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 		}
 	}
 
+	plan.EstimatedCostHour = resolveUnknownCost(plan.EstimatedCostHour)
+	plan.EstimatedCostMonth = resolveUnknownCost(plan.EstimatedCostMonth)
+
 	// Save updated data into Terraform state
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -700,6 +990,9 @@ func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateReques
 }
 
 func (r *InstanceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_instance", "Delete")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
 	tflog.Debug(ctx, "Preparing to delete item resource")
 	// Retrieve values from state
 	var state InstanceResourceModel
@@ -712,7 +1005,9 @@ func (r *InstanceResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
-	_, err := r.client.CloseClusterInstance(state.Id.ValueString())
+	span.SetAttributes(tracing.AttrInstanceID.String(state.Id.ValueString()))
+
+	_, err := r.client.CloseClusterInstance(ctx, state.Id.ValueString())
 	if err != nil && err.Error() != "Instance already closed" {
 		resp.Diagnostics.AddError(
 			"Unable to destroy Instance",
@@ -724,5 +1019,21 @@ func (r *InstanceResource) Delete(ctx context.Context, req resource.DeleteReques
 }
 
 func (r *InstanceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	instanceID, err := resolveInstanceImportID(ctx, r.client, req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to import instance",
+			err.Error(),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), instanceID)...)
+	resp.Diagnostics.Append(markCostEstimatePending(ctx, resp.Private)...)
+}
+
+func (r *InstanceResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return newStateUpgraders(instanceSchemaVersion, map[int64]rawStateUpgradeFunc{
+		0: upgradeInstanceStateV0,
+	})
 }
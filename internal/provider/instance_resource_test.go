@@ -0,0 +1,514 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"testing"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/fakeapi"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+// testAccInstanceConfig returns a spherontest_instance.test block with the
+// given attributes added to a minimal configuration.
+func testAccInstanceConfig(attributes map[string]string) string {
+	config := map[string]string{
+		"image":         `"nginx"`,
+		"tag":           `"1.25"`,
+		"cluster_name":  `"web"`,
+		"region":        `"us-east"`,
+		"machine_image": `"Ventus Nano"`,
+		"storage":       "10",
+		"replicas":      "1",
+		"ports":         `[{ container_port = 80 }]`,
+	}
+	maps.Copy(config, attributes)
+
+	return testAccResourceConfig("spherontest_instance", "test", config)
+}
+
+const (
+	testAccInstanceHealthCheck       = `{ path = "/healthz", port = 80 }`
+	testAccInstancePersistentStorage = `{ class = "SSD", mount_point = "/data", size = 10 }`
+)
+
+// testAccInstanceImportID returns the import id of the instance in state made
+// of its cluster and instance name.
+func testAccInstanceImportID(address string) resource.ImportStateIdFunc {
+	return func(state *terraform.State) (string, error) {
+		rs, ok := state.RootModule().Resources[address]
+		if !ok {
+			return "", fmt.Errorf("%s not found in state", address)
+		}
+		return rs.Primary.Attributes["cluster_name"] + "/" + rs.Primary.Attributes["name"], nil
+	}
+}
+
+func TestAccInstanceResource(t *testing.T) {
+	api := testAccFakeAPI(t)
+	address := "spherontest_instance.test"
+
+	var instanceID string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			// Import blocks are supported from Terraform 1.5.
+			tfversion.SkipBelow(tfversion.Version1_5_0),
+		},
+		CheckDestroy: testAccCheckInstancesClosed("spherontest_instance"),
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccInstanceConfig(map[string]string{
+					"env":                `{ A = "1" }`,
+					"commands":           `["nginx"]`,
+					"args":               `["-g", "daemon off;"]`,
+					"health_check":       testAccInstanceHealthCheck,
+					"persistent_storage": testAccInstancePersistentStorage,
+				}),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionCreate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(address, "id"),
+					resource.TestCheckResourceAttrSet(address, "name"),
+					resource.TestCheckResourceAttrSet(address, "cluster_id"),
+					resource.TestCheckResourceAttr(address, "image", "nginx"),
+					resource.TestCheckResourceAttr(address, "tag", "1.25"),
+					resource.TestCheckResourceAttr(address, "cluster_name", "web"),
+					resource.TestCheckResourceAttr(address, "machine_image", "Ventus Nano"),
+					resource.TestCheckResourceAttr(address, "cpu", "0.5"),
+					resource.TestCheckResourceAttr(address, "memory", "1"),
+					resource.TestCheckResourceAttr(address, "env.A", "1"),
+					resource.TestCheckResourceAttr(address, "ports.#", "1"),
+					resource.TestCheckResourceAttr(address, "ports.0.container_port", "80"),
+					resource.TestCheckResourceAttrSet(address, "ports.0.exposed_port"),
+					resource.TestCheckResourceAttrSet(address, "ports.0.url"),
+					resource.TestCheckResourceAttr(address, "provider_host", fakeapi.ProviderHost),
+					resource.TestCheckResourceAttr(address, "state", "Active"),
+					resource.TestCheckResourceAttrSet(address, "url"),
+					resource.TestCheckResourceAttrSet(address, "created_at"),
+					resource.TestCheckResourceAttrSet(address, "estimated_cost_per_hour"),
+					testAccCheckResourceID(address, &instanceID),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      address,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			// ImportState testing by cluster and instance name
+			{
+				ResourceName:      address,
+				ImportState:       true,
+				ImportStateIdFunc: testAccInstanceImportID(address),
+				ImportStateVerify: true,
+			},
+			// Importing with an import block plans clean against the config.
+			{
+				Config: testAccInstanceConfig(map[string]string{
+					"env":                `{ A = "1" }`,
+					"commands":           `["nginx"]`,
+					"args":               `["-g", "daemon off;"]`,
+					"health_check":       testAccInstanceHealthCheck,
+					"persistent_storage": testAccInstancePersistentStorage,
+				}),
+				ResourceName:    address,
+				ImportState:     true,
+				ImportStateKind: resource.ImportBlockWithID,
+				ImportPlanChecks: resource.ImportPlanChecks{
+					PreApply: []plancheck.PlanCheck{plancheck.ExpectEmptyPlan()},
+				},
+			},
+			{
+				Config: testAccInstanceConfig(map[string]string{
+					"env":                `{ A = "1" }`,
+					"commands":           `["nginx"]`,
+					"args":               `["-g", "daemon off;"]`,
+					"health_check":       testAccInstanceHealthCheck,
+					"persistent_storage": testAccInstancePersistentStorage,
+				}),
+				ResourceName:      address,
+				ImportState:       true,
+				ImportStateKind:   resource.ImportBlockWithID,
+				ImportStateIdFunc: testAccInstanceImportID(address),
+				ImportPlanChecks: resource.ImportPlanChecks{
+					PreApply: []plancheck.PlanCheck{plancheck.ExpectEmptyPlan()},
+				},
+			},
+			// Update in place
+			{
+				Config: testAccInstanceConfig(map[string]string{
+					"tag":                `"1.26"`,
+					"env":                `{ A = "2", B = "x=y" }`,
+					"args":               `["-g", "daemon off;"]`,
+					"health_check":       testAccInstanceHealthCheck,
+					"persistent_storage": testAccInstancePersistentStorage,
+				}),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "tag", "1.26"),
+					resource.TestCheckResourceAttr(address, "env.%", "2"),
+					resource.TestCheckResourceAttr(address, "env.B", "x=y"),
+					resource.TestCheckNoResourceAttr(address, "commands.#"),
+					testAccCheckResourceIDChanged(address, &instanceID, false),
+					testAccCheckInstanceOrders(api, address, 2),
+				),
+			},
+			// Replace
+			{
+				Config: testAccInstanceConfig(map[string]string{
+					"tag":                `"1.26"`,
+					"replicas":           "2",
+					"persistent_storage": testAccInstancePersistentStorage,
+				}),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionReplace),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "replicas", "2"),
+					testAccCheckResourceIDChanged(address, &instanceID, true),
+					func(*terraform.State) error {
+						for _, instance := range api.Instances() {
+							if instance.ID == instanceID && instance.State != "Closed" {
+								return fmt.Errorf("expected replaced instance %s to be closed, got %s", instanceID, instance.State)
+							}
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstanceResourceLimitsWithoutClient(t *testing.T) {
+	testAccFakeAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			// terraform_data is available from Terraform 1.4.
+			tfversion.SkipBelow(tfversion.Version1_4_0),
+		},
+		Steps: []resource.TestStep{
+			// The token is only known after apply, so the provider has no
+			// client during plan and checks specs against the default limits.
+			{
+				Config: `
+resource "terraform_data" "token" {
+  input = "` + testAccToken + `"
+}
+
+provider "spherontest" {
+  token = terraform_data.token.output
+}
+` + testAccInstanceConfig(map[string]string{"storage": "4096"}),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Storage limit exceeded"),
+			},
+		},
+	})
+}
+
+func TestAccInstanceResourceWithoutComputeCatalog(t *testing.T) {
+	api := testAccFakeAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccInstanceConfig(map[string]string{"region": `"mars"`}),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Unknown region"),
+			},
+			// Regions are not validated when the API doesn't list them.
+			{
+				PreConfig: func() {
+					api.RemoveRoute("GET /v1/compute-machine-image/regions")
+					api.RemoveRoute("GET /v1/compute-machine-image/limits")
+				},
+				Config:             testAccInstanceConfig(map[string]string{"region": `"mars"`}),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			// Specs are checked against the default limits.
+			{
+				Config:      testAccInstanceConfig(map[string]string{"storage": "4096"}),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Storage limit exceeded"),
+			},
+		},
+	})
+}
+
+func TestAccInstanceResourceWithoutPricing(t *testing.T) {
+	api := testAccFakeAPI(t)
+	api.RemoveRoute("GET /v1/compute-machine-image/pricing")
+	address := "spherontest_instance.test"
+
+	pricingRequests := 0
+	checkPricingRequests := func(*terraform.State) error {
+		if requests := api.RouteRequests("GET /v1/compute-machine-image/pricing"); requests != pricingRequests {
+			return fmt.Errorf("expected %d pricing requests, got %d", pricingRequests, requests)
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstancesClosed("spherontest_instance"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceConfig(nil),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr(address, "estimated_cost_per_hour"),
+					resource.TestCheckNoResourceAttr(address, "estimated_cost_per_month"),
+					func(*terraform.State) error {
+						pricingRequests = api.RouteRequests("GET /v1/compute-machine-image/pricing")
+						return nil
+					},
+				),
+			},
+			// Refreshes don't request pricing again for the missing estimates.
+			{
+				RefreshState: true,
+				Check:        checkPricingRequests,
+			},
+			{
+				Config:   testAccInstanceConfig(nil),
+				PlanOnly: true,
+				Check:    checkPricingRequests,
+			},
+		},
+	})
+}
+
+// testAccFindInstance returns the instance of a resource in state from the
+// fake API.
+func testAccFindInstance(api *fakeapi.Server, state *terraform.State, address string) (client.Instance, error) {
+	id, err := testAccResourceID(state, address)
+	if err != nil {
+		return client.Instance{}, err
+	}
+
+	instances := api.Instances()
+	index := slices.IndexFunc(instances, func(instance client.Instance) bool { return instance.ID == id })
+	if index < 0 {
+		return client.Instance{}, fmt.Errorf("instance %s not found", id)
+	}
+	return instances[index], nil
+}
+
+// testAccCheckInstanceOrders checks that the instance in state has the given
+// number of orders, one per deployment.
+func testAccCheckInstanceOrders(api *fakeapi.Server, address string, orders int) resource.TestCheckFunc {
+	return func(state *terraform.State) error {
+		instance, err := testAccFindInstance(api, state, address)
+		if err != nil {
+			return err
+		}
+
+		if len(instance.Orders) != orders {
+			return fmt.Errorf("expected %d orders, got %d", orders, len(instance.Orders))
+		}
+		return nil
+	}
+}
+
+// testAccCheckInstanceEnv checks that the instance in state has the given
+// number of orders and that its active order deployed env.
+func testAccCheckInstanceEnv(api *fakeapi.Server, address string, orders int, env client.Env) resource.TestCheckFunc {
+	return func(state *terraform.State) error {
+		if err := testAccCheckInstanceOrders(api, address, orders)(state); err != nil {
+			return err
+		}
+
+		instance, err := testAccFindInstance(api, state, address)
+		if err != nil {
+			return err
+		}
+
+		order, ok := api.Order(instance.ActiveOrder)
+		if !ok {
+			return fmt.Errorf("active order %s not found", instance.ActiveOrder)
+		}
+		if !slices.Contains(order.ClusterInstanceConfiguration.Env, env) {
+			return fmt.Errorf("expected env %+v to be deployed, got %+v", env, order.ClusterInstanceConfiguration.Env)
+		}
+		return nil
+	}
+}
+
+func TestAccInstanceResourceSecretEnvUpdate(t *testing.T) {
+	api := testAccFakeAPI(t)
+	address := "spherontest_instance.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstancesClosed("spherontest_instance"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceConfig(map[string]string{"env_secret": `{ S = "one" }`}),
+				Check:  testAccCheckInstanceEnv(api, address, 1, client.Env{Value: "S=one", IsSecret: true}),
+			},
+			// Changing only a secret value deploys it.
+			{
+				Config: testAccInstanceConfig(map[string]string{"env_secret": `{ S = "two" }`}),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: testAccCheckInstanceEnv(api, address, 2, client.Env{Value: "S=two", IsSecret: true}),
+			},
+			// The API masks secret values, so they can't be imported.
+			{
+				ResourceName:            address,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"env_secret"},
+			},
+		},
+	})
+}
+
+func TestAccInstanceResourceWriteOnlySecretEnv(t *testing.T) {
+	api := testAccFakeAPI(t)
+	address := "spherontest_instance.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			// Write-only attributes are supported from Terraform 1.11.
+			tfversion.SkipBelow(tfversion.Version1_11_0),
+		},
+		CheckDestroy: testAccCheckInstancesClosed("spherontest_instance"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceConfig(map[string]string{
+					"env_secret_wo":         `{ W = "one" }`,
+					"env_secret_wo_version": "1",
+				}),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr(address, "env_secret_wo.%"),
+					testAccCheckInstanceEnv(api, address, 1, client.Env{Value: "W=one", IsSecret: true}),
+				),
+			},
+			// Write-only values are only deployed when their version changes.
+			{
+				Config: testAccInstanceConfig(map[string]string{
+					"env_secret_wo":         `{ W = "two" }`,
+					"env_secret_wo_version": "1",
+				}),
+				PlanOnly: true,
+			},
+			{
+				Config: testAccInstanceConfig(map[string]string{
+					"env_secret_wo":         `{ W = "two" }`,
+					"env_secret_wo_version": "2",
+				}),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: testAccCheckInstanceEnv(api, address, 2, client.Env{Value: "W=two", IsSecret: true}),
+			},
+		},
+	})
+}
+
+func TestAccInstanceResourceDisappears(t *testing.T) {
+	testAccFakeAPI(t)
+	address := "spherontest_instance.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstancesClosed("spherontest_instance"),
+		Steps: []resource.TestStep{
+			// Closing the instance outside of Terraform removes it from state
+			// on refresh, so it's deployed again.
+			{
+				Config: testAccInstanceConfig(nil),
+				Check: func(state *terraform.State) error {
+					id, err := testAccResourceID(state, address)
+					if err != nil {
+						return err
+					}
+
+					api, err := testAccClient()
+					if err != nil {
+						return err
+					}
+					_, err = api.CloseClusterInstance(context.Background(), id)
+					return err
+				},
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccInstanceConfig(nil),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionCreate),
+					},
+				},
+				Check: resource.TestCheckResourceAttr(address, "state", "Active"),
+			},
+		},
+	})
+}
+
+func TestAccInstanceResourceFailedDeployment(t *testing.T) {
+	api := testAccFakeAPI(t)
+	address := "spherontest_instance.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstancesClosed("spherontest_instance"),
+		Steps: []resource.TestStep{
+			{
+				PreConfig:   func() { api.SetFailDeployments(true) },
+				Config:      testAccInstanceConfig(nil),
+				ExpectError: regexp.MustCompile("Instance deployment failed"),
+			},
+			// Applying again once deployments succeed creates the instance.
+			{
+				PreConfig: func() { api.SetFailDeployments(false) },
+				Config:    testAccInstanceConfig(nil),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionCreate),
+					},
+				},
+				Check: resource.TestCheckResourceAttr(address, "state", "Active"),
+			},
+		},
+	})
+}
@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"terraform-provider-spherontest/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &InstancesDataSource{}
+
+func NewInstancesDataSource() datasource.DataSource {
+	return &InstancesDataSource{}
+}
+
+type InstancesDataSource struct {
+	client *client.SpheronApi
+}
+
+type InstancesDataSourceModel struct {
+	ClusterName types.String              `tfsdk:"cluster_name"`
+	State       types.String              `tfsdk:"state"`
+	Region      types.String              `tfsdk:"region"`
+	Image       types.String              `tfsdk:"image"`
+	Instances   []InstancesDataSourceItem `tfsdk:"instances"`
+}
+
+type InstancesDataSourceItem struct {
+	Id          types.String `tfsdk:"id"`
+	Name        types.String `tfsdk:"name"`
+	ClusterName types.String `tfsdk:"cluster_name"`
+	State       types.String `tfsdk:"state"`
+	URL         types.String `tfsdk:"url"`
+	CreatedAt   types.String `tfsdk:"created_at"`
+}
+
+func (d *InstancesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_instances"
+}
+
+func (d *InstancesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists instances of the organization. All filters are optional and are combined, leaving them empty lists every instance.",
+		Attributes: map[string]schema.Attribute{
+			"cluster_name": schema.StringAttribute{
+				MarkdownDescription: "Only list instances belonging to the cluster with this name.",
+				Optional:            true,
+			},
+			"state": schema.StringAttribute{
+				MarkdownDescription: "Only list instances in this state, for example `Active` or `Closed`.",
+				Optional:            true,
+			},
+			"region": schema.StringAttribute{
+				MarkdownDescription: "Only list instances deployed to this region.",
+				Optional:            true,
+			},
+			"image": schema.StringAttribute{
+				MarkdownDescription: "Only list instances running this docker image.",
+				Optional:            true,
+			},
+			"instances": schema.ListNestedAttribute{
+				MarkdownDescription: "Instances matching the filters.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "Id of the instance.",
+							Computed:            true,
+						},
+						"name": schema.StringAttribute{
+							MarkdownDescription: "The name of the instance.",
+							Computed:            true,
+						},
+						"cluster_name": schema.StringAttribute{
+							MarkdownDescription: "The name of the cluster the instance belongs to.",
+							Computed:            true,
+						},
+						"state": schema.StringAttribute{
+							MarkdownDescription: "State of the instance.",
+							Computed:            true,
+						},
+						"url": schema.StringAttribute{
+							MarkdownDescription: "Latest preview URL of the instance.",
+							Computed:            true,
+						},
+						"created_at": schema.StringAttribute{
+							MarkdownDescription: "Creation time of the instance in RFC3339 format.",
+							Computed:            true,
+						},
+					},
+				},
+				Computed: true,
+			},
+		},
+	}
+}
+
+func (d *InstancesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerResourceData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+	d.client = data.client
+}
+
+func (d *InstancesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	tflog.Debug(ctx, "Preparing to read instances data source.")
+	var state InstancesDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	organizationID, err := d.client.GetOrganizationId(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to get organization",
+			err.Error(),
+		)
+		return
+	}
+
+	clusters, err := d.client.GetOrganizationClusters(ctx, organizationID)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to get organization clusters.",
+			err.Error(),
+		)
+		return
+	}
+
+	clusterNames := make(map[string]string, len(clusters))
+	for _, cluster := range clusters {
+		clusterNames[cluster.ID] = cluster.Name
+	}
+
+	instances, err := listOrganizationInstances(ctx, d.client, organizationID, clusters)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to list organization instances.",
+			err.Error(),
+		)
+		return
+	}
+
+	items := []InstancesDataSourceItem{}
+	for _, instance := range instances {
+		clusterName := clusterNames[instance.Cluster]
+
+		if !state.ClusterName.IsNull() && state.ClusterName.ValueString() != clusterName {
+			continue
+		}
+
+		if !state.State.IsNull() && state.State.ValueString() != instance.State {
+			continue
+		}
+
+		// Region and image are only known to the instance order, so it is only
+		// fetched when one of those filters is used.
+		if !state.Region.IsNull() || !state.Image.IsNull() {
+			if instance.ActiveOrder == "" {
+				continue
+			}
+
+			order, err := d.client.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
+			if err != nil {
+				resp.Diagnostics.AddError(
+					"Instance doesn't have provisioned deployments.",
+					err.Error(),
+				)
+				return
+			}
+
+			if order.ClusterInstanceConfiguration == nil {
+				continue
+			}
+
+			if !state.Region.IsNull() && state.Region.ValueString() != order.ClusterInstanceConfiguration.Region {
+				continue
+			}
+
+			if !state.Image.IsNull() && state.Image.ValueString() != order.ClusterInstanceConfiguration.Image {
+				continue
+			}
+		}
+
+		items = append(items, InstancesDataSourceItem{
+			Id:          types.StringValue(instance.ID),
+			Name:        types.StringValue(instance.Name),
+			ClusterName: types.StringValue(clusterName),
+			State:       types.StringValue(instance.State),
+			URL:         types.StringValue(instance.LatestURLPreview),
+			CreatedAt:   types.StringValue(formatInstanceCreatedAt(instance)),
+		})
+	}
+
+	state.Instances = items
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	tflog.Debug(ctx, "Finished reading instances data source", map[string]any{"success": true, "count": len(items)})
+}
+
+// listOrganizationInstances lists the instances of an organization. When the
+// API doesn't serve the organization instances listing, instances are listed
+// cluster by cluster instead.
+func listOrganizationInstances(ctx context.Context, api *client.SpheronApi, organizationID string, clusters []client.Cluster) ([]client.Instance, error) {
+	instances, err := api.GetOrganizationClusterInstances(ctx, organizationID)
+	if !errors.Is(err, client.ErrNotFound) {
+		return instances, err
+	}
+
+	tflog.Warn(ctx, "Organization instances listing not available, listing instances by cluster.", map[string]any{"error": err.Error()})
+
+	instances = []client.Instance{}
+	for _, cluster := range clusters {
+		clusterInstances, err := api.GetClusterInstances(ctx, cluster.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instance := range clusterInstances {
+			instance.Cluster = cluster.ID
+			instances = append(instances, instance)
+		}
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/fakeapi"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// testAccCreateClosedInstance creates an instance in cluster old outside of
+// Terraform and closes it.
+func testAccCreateClosedInstance(t *testing.T, api *fakeapi.Server) {
+	t.Helper()
+
+	spheronApi, err := testAccClient()
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	created, err := spheronApi.CreateClusterInstance(context.Background(), client.CreateInstanceRequest{
+		OrganizationID: api.OrganizationID(),
+		UniqueTopicID:  "closed-topic",
+		InstanceName:   "closed",
+		ClusterName:    "old",
+		Configuration: client.InstanceConfiguration{
+			Image:                 "nginx",
+			Tag:                   "1.24",
+			InstanceCount:         1,
+			Ports:                 []client.Port{{ContainerPort: 80}},
+			Region:                "us-east",
+			AkashMachineImageName: "Ventus Nano",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance: %s", err)
+	}
+
+	if _, err := spheronApi.CloseClusterInstance(context.Background(), created.ClusterInstanceID); err != nil {
+		t.Fatalf("unexpected error closing instance: %s", err)
+	}
+}
+
+// testAccInstancesConfig returns an nginx instance in cluster web in us-east
+// and an httpd instance in cluster api in us-west.
+func testAccInstancesConfig() string {
+	return testAccInstanceConfig(nil) + testAccResourceConfig("spherontest_instance", "api", map[string]string{
+		"image":         `"httpd"`,
+		"tag":           `"2.4"`,
+		"cluster_name":  `"api"`,
+		"region":        `"us-west"`,
+		"machine_image": `"Ventus Nano"`,
+		"storage":       "10",
+		"replicas":      "1",
+		"ports":         `[{ container_port = 80 }]`,
+	})
+}
+
+// testAccInstancesDataSourceConfig returns a spherontest_instances data source
+// named name with the given filters, read once the instances are created.
+func testAccInstancesDataSourceConfig(name string, filters map[string]string) string {
+	filters["depends_on"] = "[spherontest_instance.test, spherontest_instance.api]"
+	return testAccDataSourceConfig("spherontest_instances", name, filters)
+}
+
+func TestAccInstancesDataSource(t *testing.T) {
+	api := testAccFakeAPI(t)
+
+	config := testAccInstancesConfig() +
+		testAccInstancesDataSourceConfig("all", map[string]string{}) +
+		testAccInstancesDataSourceConfig("cluster", map[string]string{"cluster_name": `"web"`}) +
+		testAccInstancesDataSourceConfig("active", map[string]string{"state": `"Active"`}) +
+		testAccInstancesDataSourceConfig("closed", map[string]string{"state": `"Closed"`}) +
+		testAccInstancesDataSourceConfig("region", map[string]string{"region": `"us-west"`}) +
+		testAccInstancesDataSourceConfig("image", map[string]string{"image": `"httpd"`}) +
+		testAccInstancesDataSourceConfig("combined", map[string]string{"cluster_name": `"web"`, "region": `"us-east"`, "image": `"nginx"`, "state": `"Active"`}) +
+		testAccInstancesDataSourceConfig("empty", map[string]string{"cluster_name": `"web"`, "image": `"httpd"`}) +
+		testAccInstancesDataSourceConfig("missing", map[string]string{"cluster_name": `"missing"`})
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstancesClosed("spherontest_instance"),
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() { testAccCreateClosedInstance(t, api) },
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.spherontest_instances.all", "instances.#", "3"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.spherontest_instances.all", "instances.*", map[string]string{
+						"name":         "closed",
+						"cluster_name": "old",
+						"state":        "Closed",
+					}),
+					resource.TestCheckTypeSetElemAttrPair("data.spherontest_instances.all", "instances.*.id", "spherontest_instance.test", "id"),
+					resource.TestCheckTypeSetElemAttrPair("data.spherontest_instances.all", "instances.*.id", "spherontest_instance.api", "id"),
+
+					resource.TestCheckResourceAttr("data.spherontest_instances.cluster", "instances.#", "1"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instances.cluster", "instances.0.id", "spherontest_instance.test", "id"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instances.cluster", "instances.0.name", "spherontest_instance.test", "name"),
+					resource.TestCheckResourceAttr("data.spherontest_instances.cluster", "instances.0.cluster_name", "web"),
+					resource.TestCheckResourceAttr("data.spherontest_instances.cluster", "instances.0.state", "Active"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instances.cluster", "instances.0.url", "spherontest_instance.test", "url"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instances.cluster", "instances.0.created_at", "spherontest_instance.test", "created_at"),
+
+					resource.TestCheckResourceAttr("data.spherontest_instances.active", "instances.#", "2"),
+					resource.TestCheckTypeSetElemAttrPair("data.spherontest_instances.active", "instances.*.id", "spherontest_instance.test", "id"),
+					resource.TestCheckTypeSetElemAttrPair("data.spherontest_instances.active", "instances.*.id", "spherontest_instance.api", "id"),
+
+					resource.TestCheckResourceAttr("data.spherontest_instances.closed", "instances.#", "1"),
+					resource.TestCheckResourceAttr("data.spherontest_instances.closed", "instances.0.name", "closed"),
+
+					resource.TestCheckResourceAttr("data.spherontest_instances.region", "instances.#", "1"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instances.region", "instances.0.id", "spherontest_instance.api", "id"),
+
+					resource.TestCheckResourceAttr("data.spherontest_instances.image", "instances.#", "1"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instances.image", "instances.0.id", "spherontest_instance.api", "id"),
+
+					resource.TestCheckResourceAttr("data.spherontest_instances.combined", "instances.#", "1"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instances.combined", "instances.0.id", "spherontest_instance.test", "id"),
+
+					resource.TestCheckResourceAttr("data.spherontest_instances.empty", "instances.#", "0"),
+					resource.TestCheckResourceAttr("data.spherontest_instances.missing", "instances.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstancesDataSourceWithoutOrganizationListing(t *testing.T) {
+	api := testAccFakeAPI(t)
+	api.RemoveRoute("GET /v1/organization/{id}/cluster-instances")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstancesClosed("spherontest_instance"),
+		Steps: []resource.TestStep{
+			// Instances are listed cluster by cluster.
+			{
+				Config: testAccInstancesConfig() +
+					testAccInstancesDataSourceConfig("cluster", map[string]string{"cluster_name": `"web"`}) +
+					testAccInstancesDataSourceConfig("region", map[string]string{"region": `"us-west"`}),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.spherontest_instances.cluster", "instances.#", "1"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instances.cluster", "instances.0.id", "spherontest_instance.test", "id"),
+					resource.TestCheckResourceAttr("data.spherontest_instances.cluster", "instances.0.cluster_name", "web"),
+					resource.TestCheckResourceAttr("data.spherontest_instances.region", "instances.#", "1"),
+					resource.TestCheckResourceAttrPair("data.spherontest_instances.region", "instances.0.id", "spherontest_instance.api", "id"),
+				),
+			},
+		},
+	})
+}
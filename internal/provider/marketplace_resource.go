@@ -3,12 +3,12 @@ package provider
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"sort"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
-	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -22,6 +22,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 
 	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/tracing"
 
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
@@ -30,6 +31,12 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &MarketplaceInstanceResource{}
 var _ resource.ResourceWithImportState = &MarketplaceInstanceResource{}
+var _ resource.ResourceWithUpgradeState = &MarketplaceInstanceResource{}
+var _ resource.ResourceWithModifyPlan = &MarketplaceInstanceResource{}
+
+// marketplaceInstanceSchemaVersion is the current schema version of the marketplace instance
+// resource, see UpgradeState for upgrades from prior versions.
+const marketplaceInstanceSchemaVersion = 0
 
 func NewMarketplaceInstanceResource() resource.Resource {
 	return &MarketplaceInstanceResource{}
@@ -37,22 +44,33 @@ func NewMarketplaceInstanceResource() resource.Resource {
 
 // ExampleResource defines the resource implementation.
 type MarketplaceInstanceResource struct {
-	client *client.SpheronApi
+	client                       *client.SpheronApi
+	costIncreaseWarningThreshold types.Float64
 }
 
 // ExampleResourceModel describes the resource data model.
 type MarketplaceInstanceResourceModel struct {
-	Region            types.String `tfsdk:"region"`
-	Name              types.String `tfsdk:"name"`
-	MachineImage      types.String `tfsdk:"machine_image"`
-	Ports             types.List   `tfsdk:"ports"`
-	Env               types.Set    `tfsdk:"env"`
-	Id                types.String `tfsdk:"id"`
-	Cpu               types.String `tfsdk:"cpu"`
-	Memory            types.String `tfsdk:"memory"`
-	Storage           types.Int64  `tfsdk:"storage"`
-	Replicas          types.Int64  `tfsdk:"replicas"`
-	PersistentStorage types.Object `tfsdk:"persistent_storage"`
+	Region             types.String  `tfsdk:"region"`
+	Name               types.String  `tfsdk:"name"`
+	InstanceName       types.String  `tfsdk:"instance_name"`
+	TemplateId         types.String  `tfsdk:"template_id"`
+	MachineImage       types.String  `tfsdk:"machine_image"`
+	Ports              types.List    `tfsdk:"ports"`
+	Env                types.Set     `tfsdk:"env"`
+	EnvWo              types.Map     `tfsdk:"env_wo"`
+	EnvWoVersion       types.Int64   `tfsdk:"env_wo_version"`
+	Id                 types.String  `tfsdk:"id"`
+	Cpu                types.String  `tfsdk:"cpu"`
+	Memory             types.String  `tfsdk:"memory"`
+	Storage            types.Int64   `tfsdk:"storage"`
+	Replicas           types.Int64   `tfsdk:"replicas"`
+	PersistentStorage  types.Object  `tfsdk:"persistent_storage"`
+	Url                types.String  `tfsdk:"url"`
+	ProviderHost       types.String  `tfsdk:"provider_host"`
+	State              types.String  `tfsdk:"state"`
+	CreatedAt          types.String  `tfsdk:"created_at"`
+	EstimatedCostHour  types.Float64 `tfsdk:"estimated_cost_per_hour"`
+	EstimatedCostMonth types.Float64 `tfsdk:"estimated_cost_per_month"`
 }
 
 func (r *MarketplaceInstanceResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -63,6 +81,7 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 	resp.Schema = schema.Schema{
 		// This description is used by the documentation generator and the language server.
 		MarkdownDescription: "Instnce resource",
+		Version:             marketplaceInstanceSchemaVersion,
 
 		Attributes: map[string]schema.Attribute{
 			"region": schema.StringAttribute{
@@ -73,17 +92,13 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 				},
 			},
 			"machine_image": schema.StringAttribute{
-				MarkdownDescription: "Machine image name which should be used for deploying instance.",
+				MarkdownDescription: "Machine image name which should be used for deploying instance. Use `Custom Plan` or leave empty to deploy with custom `cpu` and `memory`.",
 				Optional:            true,
 				Computed:            true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 					stringplanmodifier.UseStateForUnknown(),
 				},
-				Validators: []validator.String{
-					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("memory")),
-					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("cpu")),
-				},
 			},
 			"name": schema.StringAttribute{
 				MarkdownDescription: "The name of the marketplace app.",
@@ -92,11 +107,26 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 					stringplanmodifier.RequiresReplace(),
 				},
 			},
+			"template_id": schema.StringAttribute{
+				MarkdownDescription: "Id of the marketplace app the instance was deployed from.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"instance_name": schema.StringAttribute{
+				MarkdownDescription: "Custom name of the instance. Generated by Spheron if left empty.",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
 			"storage": schema.Int64Attribute{
-				MarkdownDescription: "Instance storage in GB. Value cannot exceed 1024GB",
+				MarkdownDescription: "Instance storage in GB. The maximum is defined by Spheron and checked at plan time.",
 				Validators: []validator.Int64{
 					int64validator.AtLeast(1),
-					int64validator.AtMost(1024),
 				},
 				Required: true,
 				PlanModifiers: []planmodifier.Int64{
@@ -104,7 +134,7 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 				},
 			},
 			"cpu": schema.StringAttribute{
-				MarkdownDescription: "Instance CPU. Value cannot exceed 1024GB",
+				MarkdownDescription: "Instance CPU. When set together with a named `machine_image` it must match the CPU of that image.",
 				Optional:            true,
 				Computed:            true,
 				PlanModifiers: []planmodifier.String{
@@ -112,21 +142,11 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 					stringplanmodifier.UseStateForUnknown(),
 				},
 				Validators: []validator.String{
-					stringvalidator.OneOf(
-						"0.5",
-						"1",
-						"2",
-						"4",
-						"8",
-						"16",
-						"32",
-					),
 					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("memory")),
-					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("machine_image")),
 				},
 			},
 			"memory": schema.StringAttribute{
-				MarkdownDescription: "Instance Memory in GB.",
+				MarkdownDescription: "Instance Memory in GB. When set together with a named `machine_image` it must match the memory of that image.",
 				Optional:            true,
 				Computed:            true,
 				PlanModifiers: []planmodifier.String{
@@ -134,17 +154,7 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 					stringplanmodifier.UseStateForUnknown(),
 				},
 				Validators: []validator.String{
-					stringvalidator.OneOf(
-						"0.5",
-						"1",
-						"2",
-						"4",
-						"8",
-						"16",
-						"32",
-					),
 					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("cpu")),
-					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("machine_image")),
 				},
 			},
 			"replicas": schema.Int64Attribute{
@@ -181,11 +191,10 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 						},
 					},
 					"size": schema.Int64Attribute{
-						MarkdownDescription: "Persistent storage in GB. Value cannot exceed 1024GB",
+						MarkdownDescription: "Persistent storage in GB. The maximum is defined by Spheron and checked at plan time.",
 						Required:            true,
 						Validators: []validator.Int64{
 							int64validator.AtLeast(1),
-							int64validator.AtMost(1024),
 						},
 						PlanModifiers: []planmodifier.Int64{
 							int64planmodifier.RequiresReplace(),
@@ -208,6 +217,7 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 						"value": schema.StringAttribute{
 							MarkdownDescription: "Environment variable value.",
 							Required:            true,
+							Sensitive:           true,
 						},
 					},
 				},
@@ -216,6 +226,23 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 					setplanmodifier.RequiresReplace(),
 				},
 			},
+			"env_wo": schema.MapAttribute{
+				MarkdownDescription: "Environment variables which are never stored in state, for example passwords required by the marketplace app. Requires Terraform 1.11 or later. Change `env_wo_version` to redeploy with updated values.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Sensitive:           true,
+				WriteOnly:           true,
+			},
+			"env_wo_version": schema.Int64Attribute{
+				MarkdownDescription: "Version of `env_wo` values. Changing it redeploys the instance with the current `env_wo` values.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AlsoRequires(path.MatchRelative().AtParent().AtName("env_wo")),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
 			"ports": schema.ListNestedAttribute{
 				MarkdownDescription: "The list of port mappings",
 				NestedObject: schema.NestedAttributeObject{
@@ -234,6 +261,13 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 								int64planmodifier.UseStateForUnknown(),
 							},
 						},
+						"url": schema.StringAttribute{
+							MarkdownDescription: "URL on which the container port is reachable. Known after the deployment.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
 					},
 				},
 				Computed: true,
@@ -248,30 +282,180 @@ func (r *MarketplaceInstanceResource) Schema(ctx context.Context, req resource.S
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"url": schema.StringAttribute{
+				MarkdownDescription: "Latest preview URL of the instance.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"provider_host": schema.StringAttribute{
+				MarkdownDescription: "Host of the provider the instance is deployed on.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"state": schema.StringAttribute{
+				MarkdownDescription: "State of the instance.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"created_at": schema.StringAttribute{
+				MarkdownDescription: "Creation time of the instance in RFC3339 format.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"estimated_cost_per_hour": schema.Float64Attribute{
+				MarkdownDescription: "Estimated hourly cost of all instance replicas in USD, computed during plan from Spheron pricing.",
+				Computed:            true,
+			},
+			"estimated_cost_per_month": schema.Float64Attribute{
+				MarkdownDescription: "Estimated monthly cost of all instance replicas in USD, based on 730 hours per month.",
+				Computed:            true,
+			},
 		},
 	}
 }
 
+func (r *MarketplaceInstanceResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan when the resource is being destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	name, nameChanged, diags := getPlannedChange[types.String](ctx, req.Plan, req.State, path.Root("name"))
+	resp.Diagnostics.Append(diags...)
+	machineImage, machineImageChanged, diags := getPlannedChange[types.String](ctx, req.Plan, req.State, path.Root("machine_image"))
+	resp.Diagnostics.Append(diags...)
+	cpu, cpuChanged, diags := getPlannedChange[types.String](ctx, req.Plan, req.State, path.Root("cpu"))
+	resp.Diagnostics.Append(diags...)
+	memory, memoryChanged, diags := getPlannedChange[types.String](ctx, req.Plan, req.State, path.Root("memory"))
+	resp.Diagnostics.Append(diags...)
+	region, regionChanged, diags := getPlannedChange[types.String](ctx, req.Plan, req.State, path.Root("region"))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Catalog checks need the API client, which is not available until the
+	// provider is configured, and can't be done while the token is unknown.
+	// Compute specs are then checked against the default limits.
+	clientAvailable := apiAvailable(r.client)
+
+	if clientAvailable && (machineImageChanged || cpuChanged || memoryChanged) {
+		resp.Diagnostics.Append(validateMachineImagePlan(ctx, r.client, machineImage, cpu, memory)...)
+	}
+
+	if clientAvailable && regionChanged {
+		resp.Diagnostics.Append(validateRegionPlan(ctx, r.client, region)...)
+	}
+
+	storage, storageChanged, diags := getPlannedChange[types.Int64](ctx, req.Plan, req.State, path.Root("storage"))
+	resp.Diagnostics.Append(diags...)
+	persistentStorage, persistentStorageChanged, diags := getPlannedChange[types.Object](ctx, req.Plan, req.State, path.Root("persistent_storage"))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if machineImageChanged || cpuChanged || memoryChanged || storageChanged || persistentStorageChanged {
+		limits := getComputeLimits(ctx, r.client)
+		resp.Diagnostics.Append(validateComputeSpecsPlan(ctx, limits, machineImage, cpu, memory, storage, persistentStorage)...)
+	}
+
+	if resp.Diagnostics.HasError() || !clientAvailable {
+		return
+	}
+
+	modifyPlanCostEstimate(ctx, r.client, r.costIncreaseWarningThreshold, req, resp)
+
+	// Variables are only checked when the instance is deployed from an app,
+	// as the app can't be changed without replacing the instance.
+	if !nameChanged || name.IsUnknown() {
+		return
+	}
+
+	marketplaceApps, err := r.client.GetClusterTemplates(ctx)
+	if err != nil {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("name"),
+			"Unable to validate marketplace app.",
+			err.Error(),
+		)
+		return
+	}
+
+	marketplaceApp, err := findMarketplaceAppByName(marketplaceApps, name.ValueString())
+	if err != nil {
+		names := make([]string, 0, len(marketplaceApps))
+		for _, app := range marketplaceApps {
+			names = append(names, app.Name)
+		}
+
+		resp.Diagnostics.AddAttributeError(
+			path.Root("name"),
+			"Unknown marketplace app.",
+			fmt.Sprintf("%s. Available marketplace apps: %s", err.Error(), strings.Join(names, ", ")),
+		)
+		return
+	}
+
+	var env types.Set
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("env"), &env)...)
+	if resp.Diagnostics.HasError() || env.IsUnknown() {
+		return
+	}
+
+	envList := make([]Env, 0, len(env.Elements()))
+	resp.Diagnostics.Append(env.ElementsAs(ctx, &envList, false)...)
+
+	writeOnlyEnvs, diags := getWriteOnlyEnvMap(ctx, req.Config, path.Root("env_wo"))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	envKeys := make([]string, 0, len(envList)+len(writeOnlyEnvs))
+	for _, env := range append(envList, mapEnvMapToEnvs(writeOnlyEnvs)...) {
+		if env.Key.IsUnknown() {
+			return
+		}
+		envKeys = append(envKeys, env.Key.ValueString())
+	}
+	sort.Strings(envKeys)
+
+	resp.Diagnostics.Append(validateMarketplaceVariablesPlan(marketplaceApp, envKeys)...)
+}
+
 func (r *MarketplaceInstanceResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*client.SpheronApi)
+	data, ok := req.ProviderData.(*providerResourceData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *providerResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = data.client
+	r.costIncreaseWarningThreshold = data.costIncreaseWarningThreshold
 }
 
 func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_marketplace_instance", "Create")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
 	var plan MarketplaceInstanceResourceModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -280,7 +464,7 @@ func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.C
 		return
 	}
 
-	organization, err := r.client.GetOrganization()
+	organization, err := r.client.GetOrganization(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to get organization",
@@ -289,7 +473,7 @@ func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.C
 		return
 	}
 
-	marketplaceApps, err := r.client.GetClusterTemplates()
+	marketplaceApps, err := r.client.GetClusterTemplates(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to get available markeplace apps.",
@@ -312,6 +496,13 @@ func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.C
 	envList := make([]Env, 0, len(plan.Env.Elements()))
 	plan.Env.ElementsAs(ctx, &envList, false)
 
+	writeOnlyEnvs, diags := getWriteOnlyEnvMap(ctx, req.Config, path.Root("env_wo"))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	envList = append(envList, mapEnvMapToEnvs(writeOnlyEnvs)...)
+
 	deploymentEnv, err := checkRequiredDeploymentVariables(chosenMarketplaceApp.ServiceData.Variables, envList)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -347,16 +538,17 @@ func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.C
 		UniqueTopicID:        topicId.String(),
 		Region:               plan.Region.ValueString(),
 		InstanceCount:        int(plan.Replicas.ValueInt64()),
+		InstanceName:         plan.InstanceName.ValueString(),
 	}
 
-	if plan.MachineImage.ValueString() == "" {
+	if isCustomMachineImage(plan.MachineImage.ValueString()) {
 		customSpecs.CPU = plan.Cpu.ValueString()
 		customSpecs.Memory = fmt.Sprintf("%sGi", plan.Memory.ValueString())
 
-		plan.MachineImage = types.StringValue("Custom Plan")
+		plan.MachineImage = types.StringValue(customMachineImage)
 	} else {
 
-		computeMachines, err := r.client.GetComputeMachines()
+		computeMachines, err := r.client.GetComputeMachines(ctx)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Unable to get fetch available compute machines.",
@@ -365,7 +557,7 @@ func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.C
 			return
 		}
 
-		chosenMachineID, err := findComputeMachineID(computeMachines, plan.MachineImage.ValueString())
+		chosenMachine, err := findComputeMachine(computeMachines, plan.MachineImage.ValueString())
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Unable to get machine image by provided name.",
@@ -374,12 +566,21 @@ func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.C
 			return
 		}
 
-		instanceConfig.AkashImageID = chosenMachineID
+		if err := checkComputeMachineSpecs(chosenMachine, plan.Cpu.ValueString(), plan.Memory.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("machine_image"),
+				"Machine image doesn't match provided cpu and memory.",
+				err.Error(),
+			)
+			return
+		}
+
+		instanceConfig.AkashImageID = chosenMachine.ID
 	}
 
 	instanceConfig.CustomInstanceSpecs = customSpecs
 
-	response, err := r.client.CreateClusterInstanceFromTemplate(instanceConfig)
+	response, err := r.client.CreateClusterInstanceFromTemplate(ctx, instanceConfig)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -389,6 +590,12 @@ func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.C
 		return
 	}
 
+	span.SetAttributes(
+		tracing.AttrClusterID.String(response.ClusterID),
+		tracing.AttrInstanceID.String(response.ClusterInstanceID),
+		tracing.AttrOrderID.String(response.ClusterInstanceOrderID),
+	)
+
 	eventDataString, err := r.client.WaitForDeployedEvent(ctx, topicId.String())
 
 	if err != nil {
@@ -408,23 +615,44 @@ func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.C
 		return
 	}
 
+	order, err := r.client.GetClusterInstanceOrder(ctx, response.ClusterInstanceOrderID)
+	if err == nil {
+		err = checkOrderConfiguration(order)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Instance doesn't have provisioned deployments.",
+			err.Error(),
+		)
+		return
+	}
+
+	instance, err := r.client.GetClusterInstance(ctx, response.ClusterInstanceID)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Coudnt fetch instance by provided id.",
+			err.Error(),
+		)
+		return
+	}
+
 	plan.Id = types.StringValue(response.ClusterInstanceID)
-	plan.Ports = types.ListValueMust(types.ObjectType{AttrTypes: getPortAtrTypes()}, mapModelPortToPortValue(ports))
+	plan.TemplateId = types.StringValue(chosenMarketplaceApp.ID)
+	plan.InstanceName = types.StringValue(instance.Name)
+	plan.Ports = types.ListValueMust(types.ObjectType{AttrTypes: getPortAtrTypes()}, mapModelPortToPortValue(ports, order))
+	plan.Url = types.StringValue(instance.LatestURLPreview)
+	plan.ProviderHost = types.StringValue(getOrderProviderHost(order))
+	plan.State = types.StringValue(instance.State)
+	plan.CreatedAt = types.StringValue(formatInstanceCreatedAt(instance))
 
 	if plan.Cpu.ValueString() == "" || plan.Memory.ValueString() == "" {
-		order, err := r.client.GetClusterInstanceOrder(response.ClusterInstanceOrderID)
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Instance doesn't have provisioned deployments.",
-				err.Error(),
-			)
-			return
-		}
-
 		plan.Memory = types.StringValue(RemoveGiSuffix(order.ClusterInstanceConfiguration.AgreedMachineImage.Memory))
 		plan.Cpu = types.StringValue(fmt.Sprint(order.ClusterInstanceConfiguration.AgreedMachineImage.Cpu))
 	}
 
+	plan.EstimatedCostHour = resolveUnknownCost(plan.EstimatedCostHour)
+	plan.EstimatedCostMonth = resolveUnknownCost(plan.EstimatedCostMonth)
+
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -435,6 +663,13 @@ func (r *MarketplaceInstanceResource) Create(ctx context.Context, req resource.C
 }
 
 func (r *MarketplaceInstanceResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_marketplace_instance", "Read")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
+	if skipReadWithUnknownToken(r.client, &resp.Diagnostics) {
+		return
+	}
+
 	var state MarketplaceInstanceResourceModel
 	tflog.Debug(ctx, "Preparing to read item resource.")
 	// Get current state
@@ -444,6 +679,8 @@ func (r *MarketplaceInstanceResource) Read(ctx context.Context, req resource.Rea
 		return
 	}
 
+	span.SetAttributes(tracing.AttrInstanceID.String(state.Id.ValueString()))
+
 	if state.Id.ValueString() == "" {
 		resp.Diagnostics.AddError(
 			"Id not provided. Unable to get marketplace instance details.",
@@ -452,7 +689,7 @@ func (r *MarketplaceInstanceResource) Read(ctx context.Context, req resource.Rea
 		return
 	}
 
-	instance, err := r.client.GetClusterInstance(state.Id.ValueString())
+	instance, err := r.client.GetClusterInstance(ctx, state.Id.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Coudnt fetch instance by provided id.",
@@ -467,7 +704,7 @@ func (r *MarketplaceInstanceResource) Read(ctx context.Context, req resource.Rea
 		return
 	}
 
-	cluster, err := r.client.GetCluster(instance.Cluster)
+	cluster, err := r.client.GetCluster(ctx, instance.Cluster)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Instance cluster not found.",
@@ -476,73 +713,117 @@ func (r *MarketplaceInstanceResource) Read(ctx context.Context, req resource.Rea
 		return
 	}
 
-	order, err := r.client.GetClusterInstanceOrder(instance.ActiveOrder)
+	state.InstanceName = types.StringValue(instance.Name)
+	state.Url = types.StringValue(instance.LatestURLPreview)
+	state.State = types.StringValue(instance.State)
+	state.CreatedAt = types.StringValue(formatInstanceCreatedAt(instance))
+
+	order, err := r.client.GetClusterInstanceOrder(ctx, instance.ActiveOrder)
+	if err == nil {
+		err = checkOrderConfiguration(order)
+	}
 	if err != nil {
+		state.ProviderHost = types.StringValue("")
 		state.MachineImage = types.StringValue("")
 		state.Region = types.StringValue("")
 		state.Name = types.StringValue(cluster.Name)
+		if state.TemplateId.IsNull() {
+			state.TemplateId = types.StringValue("")
+		}
 
 		resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 
 		return
 	}
 
-	ports, diag := types.ListValue(types.ObjectType{AttrTypes: getPortAtrTypes()}, mapModelPortToPortValue(order.ClusterInstanceConfiguration.Ports))
+	ports, diag := types.ListValue(types.ObjectType{AttrTypes: getPortAtrTypes()}, mapModelPortToPortValue(order.ClusterInstanceConfiguration.Ports, order))
 	if diag.HasError() {
 		resp.Diagnostics.Append(diag.Errors()...)
 		return
 	}
 
-	if len(order.ClusterInstanceConfiguration.Env) != 0 {
-		envs, diag := types.SetValue(types.ObjectType{AttrTypes: getEnvAtrTypes()}, mapClientEnvsToEnvsValue(order.ClusterInstanceConfiguration.Env, false))
-		if diag.HasError() {
-			resp.Diagnostics.Append(diag.Errors()...)
+	templateID := state.TemplateId.ValueString()
+	if order.ClusterInstanceConfiguration.TemplateID != "" {
+		templateID = order.ClusterInstanceConfiguration.TemplateID
+	}
+
+	if templateID != "" {
+		marketplaceApps, err := r.client.GetClusterTemplates(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Unable to get available markeplace apps.",
+				err.Error(),
+			)
 			return
 		}
 
-		state.Env = envs
-	}
+		marketplaceApp, err := findMarketplaceAppByID(marketplaceApps, templateID)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Unable to get marketplace app the instance was deployed from.",
+				err.Error(),
+			)
+			return
+		}
 
-	if order.ClusterInstanceConfiguration.AgreedMachineImage.PersistentStorage != nil &&
-		order.ClusterInstanceConfiguration.AgreedMachineImage.PersistentStorage.Class != "" {
-		var pStorage = order.ClusterInstanceConfiguration.AgreedMachineImage.PersistentStorage
+		priorEnvs := make([]Env, 0, len(state.Env.Elements()))
+		resp.Diagnostics.Append(state.Env.ElementsAs(ctx, &priorEnvs, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 
-		psTypes := make(map[string]attr.Type)
-		psValues := make(map[string]attr.Value)
+		envValues := mapClientEnvsToMarketplaceEnvsValue(order.ClusterInstanceConfiguration.Env, marketplaceApp.ServiceData.Variables, priorEnvs, !state.EnvWoVersion.IsNull())
 
-		psTypes["class"] = types.StringType
-		psTypes["mount_point"] = types.StringType
-		psTypes["size"] = types.Int64Type
+		state.Env = types.SetNull(types.ObjectType{AttrTypes: getEnvAtrTypes()})
+		if len(envValues) != 0 {
+			envs, diag := types.SetValue(types.ObjectType{AttrTypes: getEnvAtrTypes()}, envValues)
+			if diag.HasError() {
+				resp.Diagnostics.Append(diag.Errors()...)
+				return
+			}
 
-		value, _ := GetStorageClassFromValue(pStorage.Class)
+			state.Env = envs
+		}
 
-		psValues["class"] = types.StringValue(value)
-		psValues["mount_point"] = types.StringValue(pStorage.MountPoint)
+		state.TemplateId = types.StringValue(marketplaceApp.ID)
+		state.Name = types.StringValue(marketplaceApp.Name)
+	} else {
+		// Instances deployed before template_id was tracked fall back to the
+		// cluster name, which matches the marketplace app name on creation.
+		state.TemplateId = types.StringValue("")
+		state.Name = types.StringValue(cluster.Name)
 
-		numberStr := pStorage.Size[:len(pStorage.Size)-2] // Remove the last two characters ("Gi")
-		number, _ := strconv.Atoi(numberStr)
-		psValues["size"] = types.Int64Value(int64(number))
+		if len(order.ClusterInstanceConfiguration.Env) != 0 {
+			envs, diag := types.SetValue(types.ObjectType{AttrTypes: getEnvAtrTypes()}, mapClientEnvsToEnvsValue(order.ClusterInstanceConfiguration.Env, false))
+			if diag.HasError() {
+				resp.Diagnostics.Append(diag.Errors()...)
+				return
+			}
 
-		state.PersistentStorage = types.ObjectValueMust(psTypes, psValues)
+			state.Env = envs
+		}
 	}
 
-	numberStr := order.ClusterInstanceConfiguration.AgreedMachineImage.Storage[:len(order.ClusterInstanceConfiguration.AgreedMachineImage.Storage)-2] // Remove the last two characters ("Gi")
-	number, _ := strconv.Atoi(numberStr)
-	state.Storage = types.Int64Value(int64(number))
-
+	state.PersistentStorage = mapClientPersistentStorageToObject(order.ClusterInstanceConfiguration.AgreedMachineImage.PersistentStorage)
+	state.Storage = types.Int64Value(parseGiSize(order.ClusterInstanceConfiguration.AgreedMachineImage.Storage))
 	state.Memory = types.StringValue(RemoveGiSuffix(order.ClusterInstanceConfiguration.AgreedMachineImage.Memory))
 	state.Cpu = types.StringValue(fmt.Sprint(order.ClusterInstanceConfiguration.AgreedMachineImage.Cpu))
 	state.Replicas = types.Int64Value(int64(order.ClusterInstanceConfiguration.InstanceCount))
 	state.Ports = ports
 	state.MachineImage = types.StringValue(order.ClusterInstanceConfiguration.AgreedMachineImage.MachineType)
 	state.Region = types.StringValue(order.ClusterInstanceConfiguration.Region)
-	state.Name = types.StringValue(cluster.Name)
+	state.ProviderHost = types.StringValue(getOrderProviderHost(order))
+
+	state.EstimatedCostHour, state.EstimatedCostMonth = readCostEstimate(ctx, r.client, req, resp, state.EstimatedCostHour, state.EstimatedCostMonth, state.MachineImage, state.Cpu, state.Memory, state.Storage, state.Replicas, state.PersistentStorage)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
 
 func (r *MarketplaceInstanceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_marketplace_instance", "Update")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
 	var plan MarketplaceInstanceResourceModel
 
 	// Retrieve values from plan
@@ -552,6 +833,11 @@ func (r *MarketplaceInstanceResource) Update(ctx context.Context, req resource.U
 		return
 	}
 
+	span.SetAttributes(tracing.AttrInstanceID.String(plan.Id.ValueString()))
+
+	plan.EstimatedCostHour = resolveUnknownCost(plan.EstimatedCostHour)
+	plan.EstimatedCostMonth = resolveUnknownCost(plan.EstimatedCostMonth)
+
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -561,6 +847,9 @@ func (r *MarketplaceInstanceResource) Update(ctx context.Context, req resource.U
 }
 
 func (r *MarketplaceInstanceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, span := startResourceSpan(ctx, "spherontest_marketplace_instance", "Delete")
+	defer endResourceSpan(span, &resp.Diagnostics)
+
 	tflog.Debug(ctx, "Preparing to delete item resource")
 	var state MarketplaceInstanceResourceModel
 
@@ -571,7 +860,9 @@ func (r *MarketplaceInstanceResource) Delete(ctx context.Context, req resource.D
 		return
 	}
 
-	_, err := r.client.CloseClusterInstance(state.Id.ValueString())
+	span.SetAttributes(tracing.AttrInstanceID.String(state.Id.ValueString()))
+
+	_, err := r.client.CloseClusterInstance(ctx, state.Id.ValueString())
 	if err != nil && err.Error() != "Instance already closed" {
 		resp.Diagnostics.AddError(
 			"Unable to destroy marketplace instance",
@@ -583,5 +874,19 @@ func (r *MarketplaceInstanceResource) Delete(ctx context.Context, req resource.D
 }
 
 func (r *MarketplaceInstanceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	instanceID, err := resolveInstanceImportID(ctx, r.client, req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to import instance",
+			err.Error(),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), instanceID)...)
+	resp.Diagnostics.Append(markCostEstimatePending(ctx, resp.Private)...)
+}
+
+func (r *MarketplaceInstanceResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return newStateUpgraders(marketplaceInstanceSchemaVersion, nil)
 }
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"maps"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+// testAccMarketplaceInstanceEnv sets the variables the Postgres template
+// requires.
+const testAccMarketplaceInstanceEnv = `[
+    { key = "POSTGRES_PASSWORD", value = "secret" },
+    { key = "POSTGRES_USER", value = "app" },
+    { key = "POSTGRES_DB", value = "app" },
+  ]`
+
+// testAccMarketplaceInstanceConfig returns a Postgres
+// spherontest_marketplace_instance.test block with the given attributes added
+// to a minimal configuration.
+func testAccMarketplaceInstanceConfig(attributes map[string]string) string {
+	config := map[string]string{
+		"name":          `"Postgres"`,
+		"region":        `"us-east"`,
+		"machine_image": `"Ventus Nano"`,
+		"storage":       "10",
+		"replicas":      "1",
+		"env":           testAccMarketplaceInstanceEnv,
+	}
+	maps.Copy(config, attributes)
+
+	return testAccResourceConfig("spherontest_marketplace_instance", "test", config)
+}
+
+func TestAccMarketplaceInstanceResource(t *testing.T) {
+	testAccFakeAPI(t)
+	address := "spherontest_marketplace_instance.test"
+
+	var instanceID string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			// Import blocks are supported from Terraform 1.5.
+			tfversion.SkipBelow(tfversion.Version1_5_0),
+		},
+		CheckDestroy: testAccCheckInstancesClosed("spherontest_marketplace_instance"),
+		Steps: []resource.TestStep{
+			// Required variables are checked at plan time.
+			{
+				Config: testAccMarketplaceInstanceConfig(map[string]string{
+					"env": `[
+    { key = "POSTGRES_USER", value = "app" },
+    { key = "POSTGRES_DB", value = "app" },
+  ]`,
+				}),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("POSTGRES_PASSWORD"),
+			},
+			// Create and Read testing
+			{
+				Config: testAccMarketplaceInstanceConfig(nil),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionCreate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(address, "id"),
+					resource.TestCheckResourceAttrSet(address, "template_id"),
+					resource.TestCheckResourceAttrSet(address, "instance_name"),
+					resource.TestCheckResourceAttr(address, "name", "Postgres"),
+					resource.TestCheckResourceAttr(address, "machine_image", "Ventus Nano"),
+					resource.TestCheckResourceAttr(address, "env.#", "3"),
+					resource.TestCheckResourceAttr(address, "ports.#", "1"),
+					resource.TestCheckResourceAttr(address, "ports.0.container_port", "5432"),
+					resource.TestCheckResourceAttrSet(address, "ports.0.exposed_port"),
+					resource.TestCheckResourceAttr(address, "state", "Active"),
+					testAccCheckResourceID(address, &instanceID),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      address,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			// Importing with an import block plans clean against the config.
+			{
+				Config:          testAccMarketplaceInstanceConfig(nil),
+				ResourceName:    address,
+				ImportState:     true,
+				ImportStateKind: resource.ImportBlockWithID,
+				ImportPlanChecks: resource.ImportPlanChecks{
+					PreApply: []plancheck.PlanCheck{plancheck.ExpectEmptyPlan()},
+				},
+			},
+			// Replace, as marketplace instances can't be updated in place.
+			{
+				Config: testAccMarketplaceInstanceConfig(map[string]string{"replicas": "2"}),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(address, plancheck.ResourceActionReplace),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "replicas", "2"),
+					testAccCheckResourceIDChanged(address, &instanceID, true),
+				),
+			},
+		},
+	})
+}
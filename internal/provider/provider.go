@@ -2,21 +2,38 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
 
 	"terraform-provider-spherontest/internal/client"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 var _ provider.Provider = &SpheronProvider{}
 
+var (
+	errUnknownToken = errors.New("The Spheron access token is unknown, as it depends on values that are only known after apply. " +
+		"Apply the resources the token depends on first, for example with terraform apply -target, " +
+		"or set the token directly, with the SPHERON_TOKEN env variable or in a config file profile.")
+	errMissingToken = errors.New("No Spheron access token is configured. " +
+		"Set the token provider attribute, the SPHERON_TOKEN env variable or the token of a config file profile.")
+)
+
 type SpheronProvider struct {
 	// version is set to the provider version on release, "dev" when the
 	// provider is built and ran locally, and "test" when running acceptance
@@ -25,7 +42,27 @@ type SpheronProvider struct {
 }
 
 type SpheronProviderModel struct {
-	Token types.String `tfsdk:"token"`
+	Token                        types.String  `tfsdk:"token"`
+	ApiUrl                       types.String  `tfsdk:"api_url"`
+	CostIncreaseWarningThreshold types.Float64 `tfsdk:"cost_increase_warning_threshold"`
+	ProxyUrl                     types.String  `tfsdk:"proxy_url"`
+	CaCertFile                   types.String  `tfsdk:"ca_cert_file"`
+	CaCertPem                    types.String  `tfsdk:"ca_cert_pem"`
+	ClientCertFile               types.String  `tfsdk:"client_cert_file"`
+	ClientCertPem                types.String  `tfsdk:"client_cert_pem"`
+	ClientKeyFile                types.String  `tfsdk:"client_key_file"`
+	ClientKeyPem                 types.String  `tfsdk:"client_key_pem"`
+	InsecureSkipVerify           types.Bool    `tfsdk:"insecure_skip_verify"`
+	UserAgentSuffix              types.String  `tfsdk:"user_agent_suffix"`
+	ConfigFile                   types.String  `tfsdk:"config_file"`
+	Profile                      types.String  `tfsdk:"profile"`
+}
+
+// providerResourceData is passed to resources and data sources when the
+// provider is configured.
+type providerResourceData struct {
+	client                       *client.SpheronApi
+	costIncreaseWarningThreshold types.Float64
 }
 
 func (p *SpheronProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -37,7 +74,73 @@ func (p *SpheronProvider) Schema(ctx context.Context, req provider.SchemaRequest
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
 			"token": schema.StringAttribute{
-				MarkdownDescription: "Spheron access token. If left empty provide SPHERON_TOKEN env variable.",
+				MarkdownDescription: "Spheron access token. If left empty SPHERON_TOKEN env variable is used, and the token of the selected profile in the config file if neither is set.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"config_file": schema.StringAttribute{
+				MarkdownDescription: "Path to the config file with named profiles. If left empty SPHERON_CONFIG_FILE env variable is used, and `~/.spheron/config` if neither is set.",
+				Optional:            true,
+			},
+			"profile": schema.StringAttribute{
+				MarkdownDescription: "Profile of the config file to read the token and API URL from. If left empty SPHERON_PROFILE env variable is used, and `" + defaultProfile + "` if neither is set.",
+				Optional:            true,
+			},
+			"api_url": schema.StringAttribute{
+				MarkdownDescription: "URL of the Spheron API. If left empty SPHERON_API_URL env variable is used, then the API URL of the selected profile in the config file, and `" + client.DefaultApiUrl + "` if none is set.",
+				Optional:            true,
+			},
+			"cost_increase_warning_threshold": schema.Float64Attribute{
+				MarkdownDescription: "Increase of the estimated monthly cost of an instance update in USD above which a warning is shown during plan. Creates are not compared, and warnings are disabled when not set.",
+				Optional:            true,
+				Validators: []validator.Float64{
+					float64validator.AtLeast(0),
+				},
+			},
+			"proxy_url": schema.StringAttribute{
+				MarkdownDescription: "URL of the proxy requests to the Spheron API are sent through, for example `http://proxy.example.com:3128`. If left empty the HTTPS_PROXY, HTTP_PROXY and NO_PROXY env variables are used.",
+				Optional:            true,
+			},
+			"ca_cert_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a PEM encoded CA bundle trusted in addition to the system certificates when connecting to the Spheron API. Conflicts with `ca_cert_pem`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("ca_cert_pem")),
+				},
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded CA bundle trusted in addition to the system certificates when connecting to the Spheron API. Conflicts with `ca_cert_file`.",
+				Optional:            true,
+			},
+			"client_cert_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a PEM encoded client certificate presented to the Spheron API for mutual TLS. Requires a client key. Conflicts with `client_cert_pem`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("client_cert_pem")),
+				},
+			},
+			"client_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded client certificate presented to the Spheron API for mutual TLS. Requires a client key. Conflicts with `client_cert_file`.",
+				Optional:            true,
+			},
+			"client_key_file": schema.StringAttribute{
+				MarkdownDescription: "Path to the PEM encoded private key of the client certificate. Conflicts with `client_key_pem`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("client_key_pem")),
+				},
+			},
+			"client_key_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded private key of the client certificate. Conflicts with `client_key_file`.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				MarkdownDescription: "Skip verification of the Spheron API certificate. Only meant for testing, as it makes connections vulnerable to interception. Defaults to `false`.",
+				Optional:            true,
+			},
+			"user_agent_suffix": schema.StringAttribute{
+				MarkdownDescription: "Text appended to the User-Agent header sent to the Spheron API, for example to tell apart traffic of different pipelines.",
 				Optional:            true,
 			},
 		},
@@ -56,30 +159,79 @@ func (p *SpheronProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
-	if config.Token.IsUnknown() {
+	if config.ApiUrl.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(
-			path.Root("token"),
-			"Unknown Spheron access token",
-			"The provider cannot create the Spheron API client as there is an unknown token value for the Spheron API token. "+
-				"Either set the value directly in the provider, or use the SPHERON_TOKEN environment variable.",
+			path.Root("api_url"),
+			"Unknown Spheron API URL",
+			"The provider cannot create the Spheron API client as there is an unknown value for the Spheron API URL. "+
+				"Either set the value directly in the provider, or use the SPHERON_API_URL environment variable.",
 		)
 	}
 
+	connectionAttributes := map[string]attr.Value{
+		"proxy_url":            config.ProxyUrl,
+		"ca_cert_file":         config.CaCertFile,
+		"ca_cert_pem":          config.CaCertPem,
+		"client_cert_file":     config.ClientCertFile,
+		"client_cert_pem":      config.ClientCertPem,
+		"client_key_file":      config.ClientKeyFile,
+		"client_key_pem":       config.ClientKeyPem,
+		"insecure_skip_verify": config.InsecureSkipVerify,
+		"user_agent_suffix":    config.UserAgentSuffix,
+		"config_file":          config.ConfigFile,
+		"profile":              config.Profile,
+	}
+
+	for name, value := range connectionAttributes {
+		if value.IsUnknown() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(name),
+				"Unknown Spheron API connection setting",
+				fmt.Sprintf("The provider cannot create the Spheron API client as there is an unknown value for %s. "+
+					"Set it to a value known during plan, or leave it out.", name),
+			)
+		}
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	token := os.Getenv("SPHERON_TOKEN")
+	transport, diags := newProviderTransport(config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	if !config.Token.IsNull() {
-		tflog.Info(ctx, "Using token from config")
+	profile, diags := loadProviderProfile(config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-		token = config.Token.ValueString()
+	token, apiUrl := providerCredentials(ctx, config, profile)
+
+	tflog.Debug(ctx, "Creating Spheron client", map[string]any{"api_url": apiUrl})
+
+	userAgent := providerUserAgent(p.version, req.TerraformVersion, config.UserAgentSuffix.ValueString())
+
+	options := []client.Option{
+		client.WithTransport(transport),
+		client.WithUserAgent(userAgent),
 	}
 
-	tflog.Debug(ctx, "Creating Spheron client")
+	// The API is only called once a resource or data source needs it, so
+	// validate and plans with an unknown token work offline.
+	switch {
+	case config.Token.IsUnknown():
+		tflog.Warn(ctx, "Spheron access token is unknown, API requests are deferred until it is known")
 
-	spheronApi, err := client.NewSpheronApi(token)
+		options = append(options, client.WithCredentialsError(errUnknownToken))
+	case token == "":
+		options = append(options, client.WithCredentialsError(errMissingToken))
+	}
+
+	spheronApi, err := client.NewSpheronApi(token, apiUrl, options...)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -91,20 +243,214 @@ func (p *SpheronProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
-	_, err = spheronApi.GetOrganization()
+	data := &providerResourceData{
+		client:                       spheronApi,
+		costIncreaseWarningThreshold: config.CostIncreaseWarningThreshold,
+	}
+	resp.DataSourceData = data
+	resp.ResourceData = data
+}
+
+// loadProviderProfile reads the selected profile of the config file. The
+// profile and file are taken from the provider attributes, then from the
+// SPHERON_PROFILE and SPHERON_CONFIG_FILE env variables, and default to the
+// default profile of ~/.spheron/config.
+func loadProviderProfile(config SpheronProviderModel) (spheronConfigProfile, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	profile := os.Getenv("SPHERON_PROFILE")
+	if !config.Profile.IsNull() {
+		profile = config.Profile.ValueString()
+	}
+
+	profileRequired := profile != ""
+	if !profileRequired {
+		profile = defaultProfile
+	}
+
+	configFile := os.Getenv("SPHERON_CONFIG_FILE")
+	if !config.ConfigFile.IsNull() {
+		configFile = config.ConfigFile.ValueString()
+	}
+
+	fileRequired := configFile != ""
+	if !fileRequired {
+		defaultPath, err := defaultConfigFilePath()
+		if err != nil {
+			if profileRequired {
+				diags.AddAttributeError(
+					path.Root("config_file"),
+					"Unable to locate Spheron config file",
+					"The home directory holding ~/.spheron/config couldn't be found, set config_file or SPHERON_CONFIG_FILE: "+err.Error(),
+				)
+			}
+			return spheronConfigProfile{}, diags
+		}
+		configFile = defaultPath
+	}
 
+	values, err := loadConfigProfile(configFile, profile, fileRequired, profileRequired)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Spheron API Client",
-			"An unexpected error occurred when creating the Spheron API client. "+
-				"If the error is not clear, please contact the provider developers.\n\n"+
-				"Spheron Client Error: "+err.Error(),
+		attribute := path.Root("config_file")
+		if errors.Is(err, errProfileNotFound) {
+			attribute = path.Root("profile")
+		}
+
+		diags.AddAttributeError(
+			attribute,
+			"Unable to read Spheron config file",
+			err.Error(),
 		)
-		return
 	}
 
-	resp.DataSourceData = spheronApi
-	resp.ResourceData = spheronApi
+	return values, diags
+}
+
+// providerCredentials returns the token and API URL of the provider. The
+// provider attributes take precedence over the SPHERON_TOKEN and
+// SPHERON_API_URL env variables, which take precedence over the profile of the
+// config file. The API URL defaults to the public Spheron API.
+func providerCredentials(ctx context.Context, config SpheronProviderModel, profile spheronConfigProfile) (string, string) {
+	token := profile.Token
+
+	if envToken := os.Getenv("SPHERON_TOKEN"); envToken != "" {
+		token = envToken
+	}
+
+	if !config.Token.IsNull() {
+		tflog.Info(ctx, "Using token from config")
+
+		token = config.Token.ValueString()
+	}
+
+	apiUrl := client.DefaultApiUrl
+
+	if profile.ApiUrl != "" {
+		apiUrl = profile.ApiUrl
+	}
+
+	if envApiUrl := os.Getenv("SPHERON_API_URL"); envApiUrl != "" {
+		apiUrl = envApiUrl
+	}
+
+	if !config.ApiUrl.IsNull() {
+		apiUrl = config.ApiUrl.ValueString()
+	}
+
+	return token, apiUrl
+}
+
+// skipReadWithUnknownToken keeps the prior state of a resource when the access
+// token is unknown, which happens during plan when the token depends on other
+// resources. The resource is refreshed once the token is known.
+func skipReadWithUnknownToken(api *client.SpheronApi, diags *diag.Diagnostics) bool {
+	if !errors.Is(api.CredentialsError(), errUnknownToken) {
+		return false
+	}
+
+	diags.AddWarning(
+		"Resource not refreshed",
+		"The Spheron access token is unknown, so the resource was not read from the Spheron API and its prior state is kept.",
+	)
+	return true
+}
+
+// providerUserAgent returns the User-Agent sent to the Spheron API, which
+// identifies the provider and Terraform versions.
+func providerUserAgent(providerVersion string, terraformVersion string, suffix string) string {
+	if terraformVersion == "" {
+		terraformVersion = "unknown"
+	}
+
+	userAgent := fmt.Sprintf("terraform-provider-spherontest/%s terraform/%s", providerVersion, terraformVersion)
+
+	if suffix = strings.TrimSpace(suffix); suffix != "" {
+		userAgent += " " + suffix
+	}
+
+	return userAgent
+}
+
+// newProviderTransport builds the transport shared by all API requests from the
+// proxy and TLS settings of the provider configuration.
+func newProviderTransport(config SpheronProviderModel) (*http.Transport, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	caCert, caDiags := readPEMSetting(config.CaCertFile, config.CaCertPem, "ca_cert_file")
+	diags.Append(caDiags...)
+
+	clientCert, certDiags := readPEMSetting(config.ClientCertFile, config.ClientCertPem, "client_cert_file")
+	diags.Append(certDiags...)
+
+	clientKey, keyDiags := readPEMSetting(config.ClientKeyFile, config.ClientKeyPem, "client_key_file")
+	diags.Append(keyDiags...)
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	if len(clientCert) > 0 && len(clientKey) == 0 {
+		diags.AddAttributeError(
+			path.Root("client_key_file"),
+			"Missing client key",
+			"A client certificate is configured without a key. Set client_key_file or client_key_pem.",
+		)
+	}
+
+	if len(clientKey) > 0 && len(clientCert) == 0 {
+		diags.AddAttributeError(
+			path.Root("client_cert_file"),
+			"Missing client certificate",
+			"A client key is configured without a certificate. Set client_cert_file or client_cert_pem.",
+		)
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	transport, err := client.NewTransport(client.TransportConfig{
+		ProxyURL:           config.ProxyUrl.ValueString(),
+		CACertPEM:          caCert,
+		ClientCertPEM:      clientCert,
+		ClientKeyPEM:       clientKey,
+		InsecureSkipVerify: config.InsecureSkipVerify.ValueBool(),
+	})
+	if err != nil {
+		diags.AddError(
+			"Invalid Spheron API connection settings",
+			"The provider cannot create the Spheron API client transport: "+err.Error(),
+		)
+		return nil, diags
+	}
+
+	return transport, diags
+}
+
+// readPEMSetting returns the PEM content of a setting that is either given
+// inline or as a path to a file.
+func readPEMSetting(file types.String, pem types.String, fileAttribute string) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if !pem.IsNull() {
+		return []byte(pem.ValueString()), diags
+	}
+
+	if file.IsNull() {
+		return nil, diags
+	}
+
+	content, err := os.ReadFile(file.ValueString())
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root(fileAttribute),
+			"Unable to read file",
+			err.Error(),
+		)
+		return nil, diags
+	}
+
+	return content, diags
 }
 
 func (p *SpheronProvider) Resources(ctx context.Context) []func() resource.Resource {
@@ -112,12 +458,16 @@ func (p *SpheronProvider) Resources(ctx context.Context) []func() resource.Resou
 		NewInstanceResource,
 		NewDomainResource,
 		NewMarketplaceInstanceResource,
+		NewClusterResource,
 	}
 }
 
 func (p *SpheronProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewOrganizationDataSource,
+		NewInstanceDataSource,
+		NewInstancesDataSource,
+		NewClusterDataSource,
 	}
 }
 
@@ -0,0 +1,744 @@
+package provider
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/fakeapi"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// testAccToken is the access token accepted by the fake API.
+const testAccToken = "test-token"
+
+// testAccProtoV6ProviderFactories are used to instantiate a provider during
+// acceptance testing. The factory function will be invoked for every Terraform
+// CLI command executed to create a provider server to which the CLI can
+// reattach.
+var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"spherontest": providerserver.NewProtocol6WithError(New("test")()),
+}
+
+// testAccPreCheck skips acceptance tests unless TF_ACC is set, as they run
+// Terraform against the fake API.
+func testAccPreCheck(t *testing.T) {
+	t.Helper()
+
+	if os.Getenv(resource.EnvTfAcc) == "" {
+		t.Skipf("Acceptance tests skipped unless env '%s' set", resource.EnvTfAcc)
+	}
+}
+
+// testAccFakeAPI starts the fake Spheron API for a test and points the
+// provider at it through the SPHERON_TOKEN and SPHERON_API_URL env variables,
+// so configs don't need a provider block. The profile settings and config
+// file of the user running the tests are cleared.
+func testAccFakeAPI(t *testing.T) *fakeapi.Server {
+	t.Helper()
+	testAccPreCheck(t)
+
+	api := fakeapi.New(testAccToken)
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	t.Setenv("SPHERON_TOKEN", testAccToken)
+	t.Setenv("SPHERON_API_URL", server.URL)
+	t.Setenv("SPHERON_CONFIG_FILE", "")
+	t.Setenv("SPHERON_PROFILE", "")
+	t.Setenv("HOME", t.TempDir())
+
+	return api
+}
+
+// testAccResourceConfig renders a resource block of the given type and name.
+// Attribute values are HCL expressions, attributes set to "" are left out.
+func testAccResourceConfig(typeName string, name string, attributes map[string]string) string {
+	return testAccBlockConfig("resource", typeName, name, attributes)
+}
+
+// testAccDataSourceConfig renders a data block like testAccResourceConfig.
+func testAccDataSourceConfig(typeName string, name string, attributes map[string]string) string {
+	return testAccBlockConfig("data", typeName, name, attributes)
+}
+
+func testAccBlockConfig(block string, typeName string, name string, attributes map[string]string) string {
+	var config strings.Builder
+	fmt.Fprintf(&config, "%s %q %q {\n", block, typeName, name)
+	for _, attribute := range slices.Sorted(maps.Keys(attributes)) {
+		if attributes[attribute] != "" {
+			fmt.Fprintf(&config, "  %s = %s\n", attribute, attributes[attribute])
+		}
+	}
+	config.WriteString("}\n")
+
+	return config.String()
+}
+
+// testAccClient returns an API client for the fake API of the test, to check
+// resources independently of the provider.
+func testAccClient() (*client.SpheronApi, error) {
+	return client.NewSpheronApi(os.Getenv("SPHERON_TOKEN"), os.Getenv("SPHERON_API_URL"))
+}
+
+// testAccCheckInstancesClosed checks that the instances of resources of the
+// given type in state were closed.
+func testAccCheckInstancesClosed(typeName string) resource.TestCheckFunc {
+	return func(state *terraform.State) error {
+		api, err := testAccClient()
+		if err != nil {
+			return err
+		}
+
+		for address, rs := range state.RootModule().Resources {
+			if rs.Type != typeName {
+				continue
+			}
+
+			instance, err := api.GetClusterInstance(context.Background(), rs.Primary.ID)
+			if err != nil {
+				return err
+			}
+			if instance.State != "Closed" {
+				return fmt.Errorf("%s: instance %s is %s, expected it to be closed", address, rs.Primary.ID, instance.State)
+			}
+		}
+		return nil
+	}
+}
+
+// testAccResourceID returns the id of a resource in state.
+func testAccResourceID(state *terraform.State, address string) (string, error) {
+	rs, ok := state.RootModule().Resources[address]
+	if !ok {
+		return "", fmt.Errorf("%s not found in state", address)
+	}
+	return rs.Primary.ID, nil
+}
+
+// testAccCheckResourceID stores the id of a resource in state in id, to
+// compare it in later steps.
+func testAccCheckResourceID(address string, id *string) resource.TestCheckFunc {
+	return func(state *terraform.State) (err error) {
+		*id, err = testAccResourceID(state, address)
+		return err
+	}
+}
+
+// testAccCheckResourceIDChanged checks whether the id of a resource differs
+// from the one stored by testAccCheckResourceID, that is whether it was
+// replaced.
+func testAccCheckResourceIDChanged(address string, id *string, changed bool) resource.TestCheckFunc {
+	return func(state *terraform.State) error {
+		current, err := testAccResourceID(state, address)
+		if err != nil {
+			return err
+		}
+
+		if changed && current == *id {
+			return fmt.Errorf("expected %s %s to be replaced", address, current)
+		}
+		if !changed && current != *id {
+			return fmt.Errorf("expected %s %s to be updated in place, got %s", address, *id, current)
+		}
+		return nil
+	}
+}
+
+func TestProviderSchema(t *testing.T) {
+	server, err := testAccProtoV6ProviderFactories["spherontest"]()
+	if err != nil {
+		t.Fatalf("unexpected error creating provider server: %s", err)
+	}
+
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error getting provider schema: %s", err)
+	}
+	for _, d := range resp.Diagnostics {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("invalid provider schema: %s: %s", d.Summary, d.Detail)
+		}
+	}
+
+	for _, typeName := range []string{"spherontest_cluster", "spherontest_domain", "spherontest_instance", "spherontest_marketplace_instance"} {
+		if _, ok := resp.ResourceSchemas[typeName]; !ok {
+			t.Errorf("resource %s not found in provider schema", typeName)
+		}
+	}
+}
+
+func TestAccProviderInvalidToken(t *testing.T) {
+	testAccFakeAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "spherontest" {
+  token = "invalid"
+}
+
+data "spherontest_organization" "test" {}
+`,
+				ExpectError: regexp.MustCompile("Invalid access token"),
+			},
+		},
+	})
+}
+
+func TestProviderUserAgent(t *testing.T) {
+	testCases := map[string]struct {
+		providerVersion  string
+		terraformVersion string
+		suffix           string
+		expected         string
+	}{
+		"release": {
+			providerVersion:  "1.2.3",
+			terraformVersion: "1.11.0",
+			expected:         "terraform-provider-spherontest/1.2.3 terraform/1.11.0",
+		},
+		"dev": {
+			providerVersion:  "dev",
+			terraformVersion: "1.5.7",
+			expected:         "terraform-provider-spherontest/dev terraform/1.5.7",
+		},
+		"unknown-terraform-version": {
+			providerVersion: "1.2.3",
+			expected:        "terraform-provider-spherontest/1.2.3 terraform/unknown",
+		},
+		"suffix": {
+			providerVersion:  "1.2.3",
+			terraformVersion: "1.11.0",
+			suffix:           "ci-pipeline",
+			expected:         "terraform-provider-spherontest/1.2.3 terraform/1.11.0 ci-pipeline",
+		},
+		"suffix-trimmed": {
+			providerVersion:  "1.2.3",
+			terraformVersion: "1.11.0",
+			suffix:           "  team/app (nightly) \n",
+			expected:         "terraform-provider-spherontest/1.2.3 terraform/1.11.0 team/app (nightly)",
+		},
+		"blank-suffix": {
+			providerVersion:  "1.2.3",
+			terraformVersion: "1.11.0",
+			suffix:           "   ",
+			expected:         "terraform-provider-spherontest/1.2.3 terraform/1.11.0",
+		},
+		"suffix-and-unknown-terraform-version": {
+			providerVersion: "test",
+			suffix:          "ci-pipeline",
+			expected:        "terraform-provider-spherontest/test terraform/unknown ci-pipeline",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			userAgent := providerUserAgent(testCase.providerVersion, testCase.terraformVersion, testCase.suffix)
+			if userAgent != testCase.expected {
+				t.Errorf("expected User-Agent %q, got %q", testCase.expected, userAgent)
+			}
+		})
+	}
+}
+
+func TestAccProviderUserAgent(t *testing.T) {
+	testCases := map[string]struct {
+		suffix   string
+		expected *regexp.Regexp
+	}{
+		"without-suffix": {
+			suffix:   "null",
+			expected: regexp.MustCompile(`^terraform-provider-spherontest/test terraform/\d+\.\d+\.\d+$`),
+		},
+		"suffix": {
+			suffix:   `"ci-pipeline"`,
+			expected: regexp.MustCompile(`^terraform-provider-spherontest/test terraform/\d+\.\d+\.\d+ ci-pipeline$`),
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			api := testAccFakeAPI(t)
+
+			resource.Test(t, resource.TestCase{
+				PreCheck:                 func() { testAccPreCheck(t) },
+				ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+				Steps: []resource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "spherontest" {
+  user_agent_suffix = %s
+}
+
+data "spherontest_organization" "test" {}
+`, testCase.suffix),
+						Check: func(*terraform.State) error {
+							userAgents := api.UserAgents()
+							if len(userAgents) == 0 {
+								return fmt.Errorf("no requests received by the fake API")
+							}
+							for _, userAgent := range userAgents {
+								if !testCase.expected.MatchString(userAgent) {
+									return fmt.Errorf("expected User-Agent to match %s, got %q", testCase.expected, userAgent)
+								}
+							}
+							return nil
+						},
+					},
+				},
+			})
+		})
+	}
+}
+
+// testConfigFileProfiles is a config file with the default profile and a
+// staging profile pointing at another API.
+const testConfigFileProfiles = `profiles:
+  default:
+    token: default-token
+  staging:
+    token: staging-token
+    api_url: https://staging.example.com
+`
+
+// setTestConfigEnv clears the env variables read by the provider, sets the
+// given ones and points HOME at a temporary directory. It returns the
+// temporary home directory.
+func setTestConfigEnv(t *testing.T, env map[string]string) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{"SPHERON_TOKEN", "SPHERON_API_URL", "SPHERON_CONFIG_FILE", "SPHERON_PROFILE"} {
+		t.Setenv(name, env[name])
+	}
+
+	return home
+}
+
+// writeTestConfigFile writes a config file at the given path relative to home.
+func writeTestConfigFile(t *testing.T, home string, name string, content string) {
+	t.Helper()
+
+	configPath := filepath.Join(home, name)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o700); err != nil {
+		t.Fatalf("unexpected error creating config directory: %s", err)
+	}
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("unexpected error writing config file: %s", err)
+	}
+}
+
+func TestLoadProviderProfile(t *testing.T) {
+	testCases := map[string]struct {
+		// files are written relative to the home directory.
+		files map[string]string
+		env   map[string]string
+		// configFile is relative to the home directory, and like the
+		// SPHERON_CONFIG_FILE env variable left out when empty.
+		configFile string
+		profile    string
+		expected   spheronConfigProfile
+		// expectedErrorAttribute is the attribute of the expected error.
+		expectedErrorAttribute string
+	}{
+		"default-file-and-profile": {
+			files:    map[string]string{".spheron/config": testConfigFileProfiles},
+			expected: spheronConfigProfile{Token: "default-token"},
+		},
+		"default-file-missing": {},
+		"default-profile-missing": {
+			files: map[string]string{".spheron/config": "profiles:\n  staging:\n    token: staging-token\n"},
+		},
+		"profile-env": {
+			files:    map[string]string{".spheron/config": testConfigFileProfiles},
+			env:      map[string]string{"SPHERON_PROFILE": "staging"},
+			expected: spheronConfigProfile{Token: "staging-token", ApiUrl: "https://staging.example.com"},
+		},
+		"profile-attribute-over-env": {
+			files:    map[string]string{".spheron/config": testConfigFileProfiles},
+			env:      map[string]string{"SPHERON_PROFILE": "staging"},
+			profile:  "default",
+			expected: spheronConfigProfile{Token: "default-token"},
+		},
+		"config-file-env": {
+			files: map[string]string{
+				".spheron/config": testConfigFileProfiles,
+				"other.yaml":      "profiles:\n  default:\n    token: other-token\n",
+			},
+			env:      map[string]string{"SPHERON_CONFIG_FILE": "other.yaml"},
+			expected: spheronConfigProfile{Token: "other-token"},
+		},
+		"config-file-attribute-over-env": {
+			files: map[string]string{
+				"other.yaml": "profiles:\n  default:\n    token: other-token\n",
+				"team.yaml":  "profiles:\n  default:\n    token: team-token\n",
+			},
+			env:        map[string]string{"SPHERON_CONFIG_FILE": "other.yaml"},
+			configFile: "team.yaml",
+			expected:   spheronConfigProfile{Token: "team-token"},
+		},
+		"missing-file": {
+			configFile:             "missing.yaml",
+			expectedErrorAttribute: "config_file",
+		},
+		"missing-file-env": {
+			env:                    map[string]string{"SPHERON_CONFIG_FILE": "missing.yaml"},
+			expectedErrorAttribute: "config_file",
+		},
+		"missing-default-file-with-profile": {
+			profile:                "staging",
+			expectedErrorAttribute: "config_file",
+		},
+		"missing-profile": {
+			files:                  map[string]string{".spheron/config": testConfigFileProfiles},
+			profile:                "production",
+			expectedErrorAttribute: "profile",
+		},
+		"missing-profile-env": {
+			files:                  map[string]string{".spheron/config": testConfigFileProfiles},
+			env:                    map[string]string{"SPHERON_PROFILE": "production"},
+			expectedErrorAttribute: "profile",
+		},
+		"malformed-file": {
+			files:                  map[string]string{".spheron/config": "profiles: [\n"},
+			expectedErrorAttribute: "config_file",
+		},
+		"malformed-profiles": {
+			files:                  map[string]string{".spheron/config": "profiles:\n  - default\n"},
+			expectedErrorAttribute: "config_file",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			home := setTestConfigEnv(t, testCase.env)
+			if configFile := testCase.env["SPHERON_CONFIG_FILE"]; configFile != "" {
+				t.Setenv("SPHERON_CONFIG_FILE", filepath.Join(home, configFile))
+			}
+			for file, content := range testCase.files {
+				writeTestConfigFile(t, home, file, content)
+			}
+
+			var config SpheronProviderModel
+			if testCase.configFile != "" {
+				config.ConfigFile = types.StringValue(filepath.Join(home, testCase.configFile))
+			}
+			if testCase.profile != "" {
+				config.Profile = types.StringValue(testCase.profile)
+			}
+
+			profile, diags := loadProviderProfile(config)
+
+			if testCase.expectedErrorAttribute == "" {
+				if diags.HasError() {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+			} else {
+				if !diags.HasError() {
+					t.Fatal("expected error diagnostics")
+				}
+
+				errorDiag, ok := diags.Errors()[0].(diag.DiagnosticWithPath)
+				if !ok || !errorDiag.Path().Equal(path.Root(testCase.expectedErrorAttribute)) {
+					t.Errorf("expected error for %s, got %v", testCase.expectedErrorAttribute, diags)
+				}
+				if summary := diags.Errors()[0].Summary(); summary != "Unable to read Spheron config file" {
+					t.Errorf("unexpected error summary: %s", summary)
+				}
+			}
+
+			if profile != testCase.expected {
+				t.Errorf("expected profile %+v, got %+v", testCase.expected, profile)
+			}
+		})
+	}
+}
+
+func TestProviderCredentials(t *testing.T) {
+	testCases := map[string]struct {
+		// configFile is the content of the default config file.
+		configFile     string
+		env            map[string]string
+		token          types.String
+		apiUrl         types.String
+		expectedToken  string
+		expectedApiUrl string
+	}{
+		"default": {
+			configFile:     "profiles: {}\n",
+			expectedApiUrl: client.DefaultApiUrl,
+		},
+		"profile": {
+			configFile:     "profiles:\n  default:\n    token: profile-token\n    api_url: https://profile.example.com\n",
+			expectedToken:  "profile-token",
+			expectedApiUrl: "https://profile.example.com",
+		},
+		"profile-without-api-url": {
+			configFile:     "profiles:\n  default:\n    token: profile-token\n",
+			expectedToken:  "profile-token",
+			expectedApiUrl: client.DefaultApiUrl,
+		},
+		"env-over-profile": {
+			configFile: "profiles:\n  default:\n    token: profile-token\n    api_url: https://profile.example.com\n",
+			env: map[string]string{
+				"SPHERON_TOKEN":   "env-token",
+				"SPHERON_API_URL": "https://env.example.com",
+			},
+			expectedToken:  "env-token",
+			expectedApiUrl: "https://env.example.com",
+		},
+		"env-without-profile": {
+			env: map[string]string{
+				"SPHERON_TOKEN":   "env-token",
+				"SPHERON_API_URL": "https://env.example.com",
+			},
+			expectedToken:  "env-token",
+			expectedApiUrl: "https://env.example.com",
+		},
+		"attribute-over-env": {
+			configFile: "profiles:\n  default:\n    token: profile-token\n    api_url: https://profile.example.com\n",
+			env: map[string]string{
+				"SPHERON_TOKEN":   "env-token",
+				"SPHERON_API_URL": "https://env.example.com",
+			},
+			token:          types.StringValue("attribute-token"),
+			apiUrl:         types.StringValue("https://attribute.example.com"),
+			expectedToken:  "attribute-token",
+			expectedApiUrl: "https://attribute.example.com",
+		},
+		"attribute-over-profile": {
+			configFile:     "profiles:\n  default:\n    token: profile-token\n    api_url: https://profile.example.com\n",
+			token:          types.StringValue("attribute-token"),
+			apiUrl:         types.StringValue("https://attribute.example.com"),
+			expectedToken:  "attribute-token",
+			expectedApiUrl: "https://attribute.example.com",
+		},
+		"token-attribute-and-api-url-env": {
+			configFile: "profiles:\n  default:\n    token: profile-token\n    api_url: https://profile.example.com\n",
+			env: map[string]string{
+				"SPHERON_API_URL": "https://env.example.com",
+			},
+			token:          types.StringValue("attribute-token"),
+			expectedToken:  "attribute-token",
+			expectedApiUrl: "https://env.example.com",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			home := setTestConfigEnv(t, testCase.env)
+			if testCase.configFile != "" {
+				writeTestConfigFile(t, home, ".spheron/config", testCase.configFile)
+			}
+
+			config := SpheronProviderModel{
+				Token:  testCase.token,
+				ApiUrl: testCase.apiUrl,
+			}
+
+			profile, diags := loadProviderProfile(config)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			token, apiUrl := providerCredentials(context.Background(), config, profile)
+			if token != testCase.expectedToken {
+				t.Errorf("expected token %q, got %q", testCase.expectedToken, token)
+			}
+			if apiUrl != testCase.expectedApiUrl {
+				t.Errorf("expected API URL %q, got %q", testCase.expectedApiUrl, apiUrl)
+			}
+		})
+	}
+}
+
+func TestNewProviderTransport(t *testing.T) {
+	testCases := map[string]struct {
+		config                 SpheronProviderModel
+		expectedProxy          string
+		expectedError          string
+		expectedErrorAttribute string
+	}{
+		"defaults": {},
+		"proxy-url": {
+			config:        SpheronProviderModel{ProxyUrl: types.StringValue("http://proxy.example.com:3128")},
+			expectedProxy: "http://proxy.example.com:3128",
+		},
+		"invalid-proxy-url": {
+			config:        SpheronProviderModel{ProxyUrl: types.StringValue("http://proxy example.com")},
+			expectedError: "Invalid Spheron API connection settings",
+		},
+		"proxy-url-without-scheme": {
+			config:        SpheronProviderModel{ProxyUrl: types.StringValue("proxy.example.com")},
+			expectedError: "Invalid Spheron API connection settings",
+		},
+		"invalid-ca-pem": {
+			config:        SpheronProviderModel{CaCertPem: types.StringValue("not a certificate")},
+			expectedError: "Invalid Spheron API connection settings",
+		},
+		"missing-ca-file": {
+			config:                 SpheronProviderModel{CaCertFile: types.StringValue(filepath.Join(t.TempDir(), "missing.pem"))},
+			expectedError:          "Unable to read file",
+			expectedErrorAttribute: "ca_cert_file",
+		},
+		"unreadable-ca-file": {
+			config:                 SpheronProviderModel{CaCertFile: types.StringValue(t.TempDir())},
+			expectedError:          "Unable to read file",
+			expectedErrorAttribute: "ca_cert_file",
+		},
+		"client-cert-without-key": {
+			config:                 SpheronProviderModel{ClientCertPem: types.StringValue("certificate")},
+			expectedError:          "Missing client key",
+			expectedErrorAttribute: "client_key_file",
+		},
+		"client-key-without-cert": {
+			config:                 SpheronProviderModel{ClientKeyPem: types.StringValue("key")},
+			expectedError:          "Missing client certificate",
+			expectedErrorAttribute: "client_cert_file",
+		},
+		"invalid-client-cert": {
+			config: SpheronProviderModel{
+				ClientCertPem: types.StringValue("certificate"),
+				ClientKeyPem:  types.StringValue("key"),
+			},
+			expectedError: "Invalid Spheron API connection settings",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("HTTPS_PROXY", "")
+			t.Setenv("HTTP_PROXY", "")
+
+			transport, diags := newProviderTransport(testCase.config)
+
+			if testCase.expectedError == "" {
+				if diags.HasError() {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+
+				request := httptest.NewRequest(http.MethodGet, client.DefaultApiUrl, nil)
+				proxy, err := transport.Proxy(request)
+				if err != nil {
+					t.Fatalf("unexpected proxy error: %s", err)
+				}
+
+				var proxyURL string
+				if proxy != nil {
+					proxyURL = proxy.String()
+				}
+				if proxyURL != testCase.expectedProxy {
+					t.Errorf("expected proxy %q, got %q", testCase.expectedProxy, proxyURL)
+				}
+				return
+			}
+
+			if !diags.HasError() {
+				t.Fatal("expected error diagnostics")
+			}
+			if transport != nil {
+				t.Error("expected no transport")
+			}
+
+			errorDiag := diags.Errors()[0]
+			if errorDiag.Summary() != testCase.expectedError {
+				t.Errorf("expected error %q, got %v", testCase.expectedError, diags)
+			}
+
+			if testCase.expectedErrorAttribute != "" {
+				pathDiag, ok := errorDiag.(diag.DiagnosticWithPath)
+				if !ok || !pathDiag.Path().Equal(path.Root(testCase.expectedErrorAttribute)) {
+					t.Errorf("expected error for %s, got %v", testCase.expectedErrorAttribute, diags)
+				}
+			}
+		})
+	}
+}
+
+func TestNewProviderTransportCustomCA(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	// The handshake rejected without the CA is expected, don't log it.
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	caCertFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caCertFile, []byte(caCert), 0o600); err != nil {
+		t.Fatalf("unexpected error writing CA file: %s", err)
+	}
+
+	testCases := map[string]struct {
+		config        SpheronProviderModel
+		expectedError bool
+	}{
+		"system-certificates": {
+			expectedError: true,
+		},
+		"ca-cert-file": {
+			config: SpheronProviderModel{CaCertFile: types.StringValue(caCertFile)},
+		},
+		"ca-cert-pem": {
+			config: SpheronProviderModel{CaCertPem: types.StringValue(caCert)},
+		},
+		"insecure-skip-verify": {
+			config: SpheronProviderModel{InsecureSkipVerify: types.BoolValue(true)},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			transport, diags := newProviderTransport(testCase.config)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			t.Cleanup(transport.CloseIdleConnections)
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if testCase.expectedError {
+				var unknownAuthority x509.UnknownAuthorityError
+				if !errors.As(err, &unknownAuthority) {
+					t.Errorf("expected an unknown certificate authority error, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error connecting to the TLS server: %s", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusNoContent {
+				t.Errorf("expected status %d, got %d", http.StatusNoContent, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// rawStateUpgradeFunc upgrades the raw JSON state of a resource by a single
+// schema version.
+type rawStateUpgradeFunc func(ctx context.Context, state map[string]any) error
+
+// newStateUpgraders returns state upgraders from every prior schema version to
+// currentVersion. steps[v] upgrades the state from version v to v+1, states of
+// older versions run all following steps in order. Versions without a step
+// only bump the version. Attributes which are no longer part of the schema
+// are dropped after the upgrade.
+func newStateUpgraders(currentVersion int64, steps map[int64]rawStateUpgradeFunc) map[int64]resource.StateUpgrader {
+	upgraders := make(map[int64]resource.StateUpgrader, currentVersion)
+	for version := int64(0); version < currentVersion; version++ {
+		fromVersion := version
+		upgraders[fromVersion] = resource.StateUpgrader{
+			StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+				upgradeRawState(ctx, req, resp, fromVersion, currentVersion, steps)
+			},
+		}
+	}
+	return upgraders
+}
+
+func upgradeRawState(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse, fromVersion, toVersion int64, steps map[int64]rawStateUpgradeFunc) {
+	if req.RawState == nil || req.RawState.JSON == nil {
+		resp.Diagnostics.AddError(
+			"Unable to upgrade resource state",
+			fmt.Sprintf("Missing JSON state of schema version %d.", fromVersion),
+		)
+		return
+	}
+
+	var state map[string]any
+	if err := json.Unmarshal(req.RawState.JSON, &state); err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to upgrade resource state",
+			err.Error(),
+		)
+		return
+	}
+
+	for version := fromVersion; version < toVersion; version++ {
+		step, ok := steps[version]
+		if !ok {
+			continue
+		}
+
+		if err := step(ctx, state); err != nil {
+			resp.Diagnostics.AddError(
+				"Unable to upgrade resource state",
+				fmt.Sprintf("Upgrade from schema version %d failed: %s", version, err.Error()),
+			)
+			return
+		}
+	}
+
+	attributes := resp.State.Schema.GetAttributes()
+	for name := range state {
+		if _, ok := attributes[name]; !ok {
+			delete(state, name)
+		}
+	}
+
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to upgrade resource state",
+			err.Error(),
+		)
+		return
+	}
+
+	resp.DynamicValue = &tfprotov6.DynamicValue{JSON: stateJSON}
+	tflog.Debug(ctx, "Upgraded resource state", map[string]any{"from_version": fromVersion, "to_version": toVersion})
+}
+
+// upgradeInstanceStateV0 converts env and env_secret from sets of key/value
+// objects to maps.
+func upgradeInstanceStateV0(ctx context.Context, state map[string]any) error {
+	for _, name := range []string{"env", "env_secret"} {
+		state[name] = upgradeEnvListToMap(state[name])
+	}
+	return nil
+}
+
+// upgradeEnvListToMap converts a version 0 list of key/value env objects to
+// an env map. Entries without a key are dropped.
+func upgradeEnvListToMap(value any) any {
+	envList, ok := value.([]any)
+	if !ok || len(envList) == 0 {
+		return nil
+	}
+
+	envMap := make(map[string]any, len(envList))
+	for _, item := range envList {
+		env, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		key, _ := env["key"].(string)
+		if key == "" {
+			continue
+		}
+
+		envValue, _ := env["value"].(string)
+		envMap[key] = envValue
+	}
+
+	return envMap
+}
@@ -0,0 +1,254 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// upgradeResourceState upgrades a raw JSON state through the provider server,
+// as Terraform does, and returns the upgraded state decoded with the current
+// schema of the resource.
+func upgradeResourceState(t *testing.T, typeName string, version int64, rawState string) (map[string]tftypes.Value, []*tfprotov6.Diagnostic) {
+	t.Helper()
+
+	ctx := context.Background()
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatalf("unexpected error creating provider server: %s", err)
+	}
+
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error getting provider schema: %s", err)
+	}
+
+	resourceSchema, ok := schemaResp.ResourceSchemas[typeName]
+	if !ok {
+		t.Fatalf("resource %s not found in provider schema", typeName)
+	}
+
+	resp, err := server.UpgradeResourceState(ctx, &tfprotov6.UpgradeResourceStateRequest{
+		TypeName: typeName,
+		Version:  version,
+		RawState: &tfprotov6.RawState{JSON: []byte(rawState)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error upgrading state: %s", err)
+	}
+	if len(resp.Diagnostics) > 0 {
+		return nil, resp.Diagnostics
+	}
+
+	value, err := resp.UpgradedState.Unmarshal(resourceSchema.ValueType())
+	if err != nil {
+		t.Fatalf("upgraded state doesn't match the current schema: %s", err)
+	}
+
+	var attributes map[string]tftypes.Value
+	if err := value.As(&attributes); err != nil {
+		t.Fatalf("unexpected error reading upgraded state: %s", err)
+	}
+
+	return attributes, nil
+}
+
+func TestInstanceStateUpgradeV0(t *testing.T) {
+	envMapType := tftypes.Map{ElementType: tftypes.String}
+
+	testCases := map[string]struct {
+		env               string
+		envSecret         string
+		expectedEnv       tftypes.Value
+		expectedEnvSecret tftypes.Value
+	}{
+		"set-to-map": {
+			env:       `[{"key": "A", "value": "1"}, {"key": "B", "value": "x=y"}]`,
+			envSecret: `[{"key": "PASSWORD", "value": "secret"}]`,
+			expectedEnv: tftypes.NewValue(envMapType, map[string]tftypes.Value{
+				"A": tftypes.NewValue(tftypes.String, "1"),
+				"B": tftypes.NewValue(tftypes.String, "x=y"),
+			}),
+			expectedEnvSecret: tftypes.NewValue(envMapType, map[string]tftypes.Value{
+				"PASSWORD": tftypes.NewValue(tftypes.String, "secret"),
+			}),
+		},
+		"empty-value-and-missing-key": {
+			env:       `[{"key": "EMPTY", "value": ""}, {"key": "", "value": "dropped"}, {"value": "dropped"}]`,
+			envSecret: `[]`,
+			expectedEnv: tftypes.NewValue(envMapType, map[string]tftypes.Value{
+				"EMPTY": tftypes.NewValue(tftypes.String, ""),
+			}),
+			expectedEnvSecret: tftypes.NewValue(envMapType, nil),
+		},
+		"null": {
+			env:               `null`,
+			envSecret:         `null`,
+			expectedEnv:       tftypes.NewValue(envMapType, nil),
+			expectedEnvSecret: tftypes.NewValue(envMapType, nil),
+		},
+		"empty": {
+			env:               `[]`,
+			envSecret:         `[]`,
+			expectedEnv:       tftypes.NewValue(envMapType, nil),
+			expectedEnvSecret: tftypes.NewValue(envMapType, nil),
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rawState := fmt.Sprintf(`{
+				"image": "nginx",
+				"tag": "latest",
+				"cluster_name": "app",
+				"storage": 10,
+				"cpu": null,
+				"memory": null,
+				"replicas": 1,
+				"ports": [{"container_port": 80, "exposed_port": 80}],
+				"env": %s,
+				"env_secret": %s,
+				"commands": null,
+				"args": null,
+				"region": "any",
+				"machine_image": "Ventus Nano",
+				"health_check": null,
+				"persistent_storage": null,
+				"id": "instance-id"
+			}`, testCase.env, testCase.envSecret)
+
+			attributes, diags := upgradeResourceState(t, "spherontest_instance", 0, rawState)
+			if len(diags) > 0 {
+				t.Fatalf("unexpected diagnostics: %s: %s", diags[0].Summary, diags[0].Detail)
+			}
+
+			if !attributes["env"].Equal(testCase.expectedEnv) {
+				t.Errorf("expected env %s, got %s", testCase.expectedEnv, attributes["env"])
+			}
+			if !attributes["env_secret"].Equal(testCase.expectedEnvSecret) {
+				t.Errorf("expected env_secret %s, got %s", testCase.expectedEnvSecret, attributes["env_secret"])
+			}
+
+			if !attributes["id"].Equal(tftypes.NewValue(tftypes.String, "instance-id")) {
+				t.Errorf("expected id to be kept, got %s", attributes["id"])
+			}
+			if !attributes["storage"].Equal(tftypes.NewValue(tftypes.Number, 10)) {
+				t.Errorf("expected storage to be kept, got %s", attributes["storage"])
+			}
+
+			// Attributes added after version 0 start out null.
+			if !attributes["env_secret_wo_version"].IsNull() {
+				t.Errorf("expected env_secret_wo_version to be null, got %s", attributes["env_secret_wo_version"])
+			}
+		})
+	}
+}
+
+func TestInstanceStateUpgradeV0InvalidJSON(t *testing.T) {
+	_, diags := upgradeResourceState(t, "spherontest_instance", 0, `{"env": [`)
+	if len(diags) == 0 {
+		t.Fatal("expected diagnostics for invalid JSON state")
+	}
+
+	if diags[0].Summary != "Unable to upgrade resource state" {
+		t.Errorf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+}
+
+func TestNewStateUpgraders(t *testing.T) {
+	stateSchema := schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{Computed: true},
+			"steps": schema.ListAttribute{
+				ElementType: types.Int64Type,
+				Computed:    true,
+			},
+		},
+	}
+
+	recordStep := func(version int64) rawStateUpgradeFunc {
+		return func(ctx context.Context, state map[string]any) error {
+			steps, _ := state["steps"].([]any)
+			state["steps"] = append(steps, version)
+			return nil
+		}
+	}
+
+	upgraders := newStateUpgraders(3, map[int64]rawStateUpgradeFunc{
+		0: recordStep(0),
+		2: recordStep(2),
+	})
+
+	if len(upgraders) != 3 {
+		t.Fatalf("expected upgraders for versions 0 to 2, got %d", len(upgraders))
+	}
+
+	testCases := map[int64][]any{
+		0: {float64(0), float64(2)},
+		1: {float64(2)},
+		2: {float64(2)},
+	}
+
+	for version, expectedSteps := range testCases {
+		t.Run(fmt.Sprintf("version-%d", version), func(t *testing.T) {
+			req := resource.UpgradeStateRequest{
+				RawState: &tfprotov6.RawState{JSON: []byte(`{"id": "id", "removed": true}`)},
+			}
+			resp := &resource.UpgradeStateResponse{
+				State: tfsdk.State{Schema: stateSchema},
+			}
+
+			upgraders[version].StateUpgrader(context.Background(), req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var state map[string]any
+			if err := json.Unmarshal(resp.DynamicValue.JSON, &state); err != nil {
+				t.Fatalf("unexpected error decoding upgraded state: %s", err)
+			}
+
+			expected := map[string]any{"id": "id", "steps": expectedSteps}
+			if !reflect.DeepEqual(state, expected) {
+				t.Errorf("expected state %v, got %v", expected, state)
+			}
+		})
+	}
+}
+
+func TestNewStateUpgradersStepError(t *testing.T) {
+	upgraders := newStateUpgraders(1, map[int64]rawStateUpgradeFunc{
+		0: func(ctx context.Context, state map[string]any) error {
+			return fmt.Errorf("invalid env")
+		},
+	})
+
+	req := resource.UpgradeStateRequest{
+		RawState: &tfprotov6.RawState{JSON: []byte(`{}`)},
+	}
+	resp := &resource.UpgradeStateResponse{}
+
+	upgraders[0].StateUpgrader(context.Background(), req, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error diagnostics")
+	}
+
+	expectedDetail := "Upgrade from schema version 0 failed: invalid env"
+	if detail := resp.Diagnostics.Errors()[0].Detail(); detail != expectedDetail {
+		t.Errorf("expected detail %q, got %q", expectedDetail, detail)
+	}
+	if resp.DynamicValue != nil {
+		t.Error("expected no upgraded state")
+	}
+}
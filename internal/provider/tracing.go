@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"terraform-provider-spherontest/internal/tracing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startResourceSpan starts the span of a resource CRUD operation, which the
+// spans of API calls made by the operation are nested under.
+func startResourceSpan(ctx context.Context, resourceType string, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	attributes = append(attributes,
+		tracing.AttrResource.String(resourceType),
+		tracing.AttrTFOperation.String(operation),
+	)
+
+	return tracing.Start(ctx, resourceType+" "+operation, attributes...)
+}
+
+// endResourceSpan ends the span of a resource operation, marking it as failed
+// when the operation returned error diagnostics.
+func endResourceSpan(span trace.Span, diags *diag.Diagnostics) {
+	for _, d := range diags.Errors() {
+		tracing.RecordError(span, errors.New(d.Summary()))
+	}
+
+	span.End()
+}
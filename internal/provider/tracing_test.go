@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"terraform-provider-spherontest/internal/tracing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// testAccTracing installs a tracer provider recording spans into an in-memory
+// exporter for the duration of the test.
+func testAccTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider, err := tracing.NewTracerProvider(context.Background(), "test", sdktrace.WithSyncer(exporter))
+	if err != nil {
+		t.Fatalf("unexpected error creating tracer provider: %s", err)
+	}
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracerProvider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = tracerProvider.Shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+// spanAttribute returns the value of an attribute of a span.
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) (string, bool) {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value.Emit(), true
+		}
+	}
+	return "", false
+}
+
+// childSpans returns the spans started within the given span.
+func childSpans(spans tracetest.SpanStubs, parent tracetest.SpanStub) tracetest.SpanStubs {
+	var children tracetest.SpanStubs
+	for _, span := range spans {
+		if span.Parent.SpanID() == parent.SpanContext.SpanID() {
+			children = append(children, span)
+		}
+	}
+	return children
+}
+
+func TestAccResourceTracing(t *testing.T) {
+	testAccFakeAPI(t)
+	exporter := testAccTracing(t)
+
+	brokenDomain := testAccResourceConfig("spherontest_domain", "broken", map[string]string{
+		"name":          `"broken.example.com"`,
+		"type":          `"domain"`,
+		"instance_port": "8080",
+		"instance_id":   "spherontest_instance.test.id",
+	})
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckDomainsDeleted,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDomainConfig(nil),
+			},
+			{
+				Config:      testAccDomainConfig(nil) + brokenDomain,
+				ExpectError: regexp.MustCompile("no urls"),
+			},
+		},
+	})
+
+	spans := exporter.GetSpans()
+
+	var instanceCreate, domainCreate, brokenCreate *tracetest.SpanStub
+	for i, span := range spans {
+		switch span.Name {
+		case "spherontest_instance Create":
+			instanceCreate = &spans[i]
+		case "spherontest_domain Create":
+			if span.Status.Code == codes.Error {
+				brokenCreate = &spans[i]
+			} else {
+				domainCreate = &spans[i]
+			}
+		}
+	}
+
+	if instanceCreate == nil || domainCreate == nil || brokenCreate == nil {
+		t.Fatalf("expected instance and domain create spans, got %d spans", len(spans))
+	}
+
+	if id, ok := spanAttribute(*instanceCreate, tracing.AttrInstanceID); !ok || id == "" {
+		t.Errorf("expected instance create span to have %s set", tracing.AttrInstanceID)
+	}
+	if operation, _ := spanAttribute(*instanceCreate, tracing.AttrTFOperation); operation != "Create" {
+		t.Errorf("expected instance create span to have operation Create, got %q", operation)
+	}
+
+	for _, key := range []attribute.Key{tracing.AttrInstanceID, tracing.AttrDomainID} {
+		if id, ok := spanAttribute(*domainCreate, key); !ok || id == "" {
+			t.Errorf("expected domain create span to have %s set", key)
+		}
+	}
+
+	for name, parent := range map[string]*tracetest.SpanStub{"instance": instanceCreate, "domain": domainCreate} {
+		children := childSpans(spans, *parent)
+		if len(children) == 0 {
+			t.Errorf("expected %s create span to have API call spans", name)
+		}
+		for _, child := range children {
+			if !strings.HasPrefix(child.Name, "Spheron API ") {
+				t.Errorf("unexpected %s create child span %q", name, child.Name)
+			}
+		}
+	}
+
+	if brokenCreate.Status.Description != "Unable to create domain for instance333, no urls" {
+		t.Errorf("expected failed domain create span to describe the error, got %q", brokenCreate.Status.Description)
+	}
+	if len(brokenCreate.Events) == 0 || brokenCreate.Events[0].Name != "exception" {
+		t.Errorf("expected failed domain create span to record the error, got events %+v", brokenCreate.Events)
+	}
+}
@@ -1,24 +1,59 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"terraform-provider-spherontest/internal/client"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-func findComputeMachineID(machines []client.ComputeMachine, name string) (string, error) {
+// customMachineImage is the machine image name Spheron reports for instances
+// deployed with custom cpu and memory specs.
+const customMachineImage = "Custom Plan"
+
+func isCustomMachineImage(name string) bool {
+	return name == "" || name == customMachineImage
+}
+
+func findComputeMachine(machines []client.ComputeMachine, name string) (client.ComputeMachine, error) {
 	for _, machine := range machines {
 		if machine.Name == name {
-			return machine.ID, nil
+			return machine, nil
 		}
 	}
 
-	return "", errors.New("ComputeMachine not found with the provided ID")
+	return client.ComputeMachine{}, errors.New("ComputeMachine not found with the provided ID")
+}
+
+// checkComputeMachineSpecs verifies that cpu and memory set next to a machine
+// image match the specs of that image. Empty values are not checked.
+func checkComputeMachineSpecs(machine client.ComputeMachine, cpu string, memory string) error {
+	if cpu != "" && cpu != fmt.Sprint(machine.Cpu) {
+		return fmt.Errorf("Machine image %s has %v CPU, got: %s", machine.Name, machine.Cpu, cpu)
+	}
+
+	if memory != "" && memory != RemoveGiSuffix(machine.Memory) {
+		return fmt.Errorf("Machine image %s has %s memory, got: %sGi", machine.Name, machine.Memory, memory)
+	}
+
+	return nil
 }
 
 func findMarketplaceAppByName(apps []client.MarketplaceApp, name string) (client.MarketplaceApp, error) {
@@ -65,27 +100,84 @@ func checkRequiredDeploymentVariables(appVariables []client.MarketplaceAppVariab
 		}
 	}
 
-	for varName, isMissing := range missingVariables {
-		if isMissing {
-			return nil, errors.New(fmt.Sprintf("Missing required deployment variable: %s", varName))
+	missing := []string{}
+	for _, appVar := range appVariables {
+		if missingVariables[appVar.Name] {
+			missing = append(missing, appVar.Name)
 		}
 	}
 
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("Missing required deployment variables: %s", strings.Join(missing, ", "))
+	}
+
 	return deploymentVariables, nil
 }
 
-func mapModelPortToPortValue(portList []client.Port) []attr.Value {
+func findMarketplaceAppByID(apps []client.MarketplaceApp, id string) (client.MarketplaceApp, error) {
+	for _, app := range apps {
+		if app.ID == id {
+			return app, nil
+		}
+	}
+
+	return client.MarketplaceApp{}, fmt.Errorf("MarketplaceApp not found with id: %s", id)
+}
+
+// mapClientEnvsToMarketplaceEnvsValue maps order envs back to marketplace app
+// variables. Envs not defined by the app are dropped, and variables left at
+// their default value are only kept when they are part of the prior state.
+// With onlyPrior set, variables missing from the prior state are dropped as
+// they are managed through a write-only attribute.
+func mapClientEnvsToMarketplaceEnvsValue(clientEnvs []client.Env, appVariables []client.MarketplaceAppVariable, priorEnvs []Env, onlyPrior bool) []attr.Value {
+	priorValues := make(map[string]string, len(priorEnvs))
+	for _, env := range priorEnvs {
+		priorValues[env.Key.ValueString()] = env.Value.ValueString()
+	}
+
+	envList := make([]attr.Value, 0, len(clientEnvs))
+
+	for _, clientEnv := range clientEnvs {
+		keyString, valueString, ok := splitEnvValue(clientEnv.Value)
+		if !ok {
+			continue
+		}
+
+		for _, appVar := range appVariables {
+			if appVar.Name != keyString && appVar.Label != keyString {
+				continue
+			}
+
+			priorValue, inPrior := priorValues[appVar.Name]
+
+			if !inPrior && (onlyPrior || valueString == appVar.DefaultValue) {
+				break
+			}
+
+			if inPrior && clientEnv.IsSecret && isMaskedSecretValue(valueString) {
+				valueString = priorValue
+			}
+
+			envList = append(envList, types.ObjectValueMust(getEnvAtrTypes(), map[string]attr.Value{
+				"key":   types.StringValue(appVar.Name),
+				"value": types.StringValue(valueString),
+			}))
+			break
+		}
+	}
+
+	return envList
+}
+
+func mapModelPortToPortValue(portList []client.Port, order client.InstanceOrder) []attr.Value {
 	ports := make([]attr.Value, len(portList))
 	for i, pm := range portList {
-		portTypes := make(map[string]attr.Type)
 		portValues := make(map[string]attr.Value)
 
-		portTypes["container_port"] = types.Int64Type
-		portTypes["exposed_port"] = types.Int64Type
-
 		portValues["container_port"] = types.Int64Value(int64(pm.ContainerPort))
 		portValues["exposed_port"] = types.Int64Value(int64(pm.ExposedPort))
-		port := types.ObjectValueMust(portTypes, portValues)
+		portValues["url"] = types.StringValue(getInstanceDeploymentURL(order, pm.ContainerPort))
+		port := types.ObjectValueMust(getPortAtrTypes(), portValues)
 
 		ports[i] = port
 	}
@@ -96,6 +188,7 @@ func getPortAtrTypes() map[string]attr.Type {
 	return map[string]attr.Type{
 		"container_port": types.Int64Type,
 		"exposed_port":   types.Int64Type,
+		"url":            types.StringType,
 	}
 }
 
@@ -111,8 +204,10 @@ func mapClientEnvsToEnvsValue(clientEnvs []client.Env, isSecret bool) []attr.Val
 			continue
 		}
 
-		split := strings.SplitN(clientEnv.Value, "=", 2)
-		keyString, valueString := split[0], split[1]
+		keyString, valueString, ok := splitEnvValue(clientEnv.Value)
+		if !ok {
+			continue
+		}
 
 		portTypes := make(map[string]attr.Type)
 		portValues := make(map[string]attr.Value)
@@ -152,58 +247,344 @@ func mapPortToPortModel(portList []Port) []client.Port {
 	return ports
 }
 
-func mapModelPortToPort(portList []client.Port) []Port {
+func mapModelPortToPort(portList []client.Port, order client.InstanceOrder) []Port {
 	ports := []Port{}
 	for _, pm := range portList {
 		port := Port{
 			ContainerPort: types.Int64Value(int64(pm.ContainerPort)),
 			ExposedPort:   types.Int64Value(int64(pm.ExposedPort)),
+			URL:           types.StringValue(getInstanceDeploymentURL(order, pm.ContainerPort)),
 		}
 		ports = append(ports, port)
 	}
 	return ports
 }
 
-func mapEnvsToClientEnvs(envList []Env, isSecret bool) []client.Env {
-	clientEnvs := make([]client.Env, 0, len(envList))
-	for _, env := range envList {
-		clientEnv := client.Env{
-			Value:    env.Key.ValueString() + "=" + env.Value.ValueString(),
-			IsSecret: isSecret,
+// getWriteOnlyEnvMap reads a write-only env map from the configuration, as
+// write-only values are never part of the plan or state.
+func getWriteOnlyEnvMap(ctx context.Context, config tfsdk.Config, attributePath path.Path) (map[string]string, diag.Diagnostics) {
+	var envMap types.Map
+
+	diags := config.GetAttribute(ctx, attributePath, &envMap)
+	if diags.HasError() || envMap.IsNull() || envMap.IsUnknown() {
+		return nil, diags
+	}
+
+	values := make(map[string]string, len(envMap.Elements()))
+	diags.Append(envMap.ElementsAs(ctx, &values, false)...)
+
+	return values, diags
+}
+
+func mapEnvMapToEnvs(envMap map[string]string) []Env {
+	keys := make([]string, 0, len(envMap))
+	for key := range envMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	envList := make([]Env, 0, len(envMap))
+	for _, key := range keys {
+		envList = append(envList, Env{
+			Key:   types.StringValue(key),
+			Value: types.StringValue(envMap[key]),
+		})
+	}
+	return envList
+}
+
+// isMaskedSecretValue reports whether the API returned a masked value, a
+// non-empty run of "*", instead of the actual secret.
+func isMaskedSecretValue(value string) bool {
+	return value != "" && strings.Trim(value, "*") == ""
+}
+
+// mergeSecretEnvs reconciles secret envs read from the API with the prior
+// state. Masked values keep their prior state value, and when onlyPrior is set
+// envs that are not part of the prior state are dropped as they are managed
+// through a write-only attribute.
+func mergeSecretEnvs(readEnvs map[string]string, priorEnvs map[string]string, onlyPrior bool) map[string]string {
+	envMap := make(map[string]string, len(readEnvs))
+	for key, value := range readEnvs {
+		priorValue, inPrior := priorEnvs[key]
+
+		if onlyPrior && !inPrior {
+			continue
 		}
-		clientEnvs = append(clientEnvs, clientEnv)
+
+		if inPrior && isMaskedSecretValue(value) {
+			value = priorValue
+		}
+
+		envMap[key] = value
 	}
-	return clientEnvs
+
+	return envMap
 }
 
-func mapClientEnvsToEnvs(clientEnvs []client.Env, isSecret bool) []Env {
-	envList := make([]Env, 0, len(clientEnvs))
+// secretEnvsEqual reports whether planned secret envs match the ones
+// deployed, as merged with the prior state by mergeSecretEnvs. Values which
+// are still masked belong to write-only envs, which are compared by their
+// version instead.
+func secretEnvsEqual(planEnvs map[string]string, deployedEnvs map[string]string) bool {
+	return maps.EqualFunc(planEnvs, deployedEnvs, func(planValue string, deployedValue string) bool {
+		return planValue == deployedValue || isMaskedSecretValue(deployedValue)
+	})
+}
 
+// splitEnvValue decodes a KEY=value env string. Values without "=" are
+// treated as a key with an empty value, empty keys are rejected.
+func splitEnvValue(value string) (string, string, bool) {
+	key, envValue, _ := strings.Cut(value, "=")
+	if key == "" {
+		return "", "", false
+	}
+	return key, envValue, true
+}
+
+func mapEnvMapToClientEnvs(envMap map[string]string, isSecret bool) []client.Env {
+	keys := make([]string, 0, len(envMap))
+	for key := range envMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clientEnvs := make([]client.Env, 0, len(envMap))
+	for _, key := range keys {
+		clientEnvs = append(clientEnvs, client.Env{
+			Value:    key + "=" + envMap[key],
+			IsSecret: isSecret,
+		})
+	}
+	return clientEnvs
+}
+
+func mapClientEnvsToEnvMap(clientEnvs []client.Env, isSecret bool) map[string]string {
+	envMap := make(map[string]string, len(clientEnvs))
 	for _, clientEnv := range clientEnvs {
 		if clientEnv.IsSecret != isSecret {
 			continue
 		}
 
-		split := strings.SplitN(clientEnv.Value, "=", 2)
-		keyString, valueString := split[0], split[1]
+		key, value, ok := splitEnvValue(clientEnv.Value)
+		if !ok {
+			continue
+		}
+
+		envMap[key] = value
+	}
+	return envMap
+}
 
-		newEnv := Env{
-			Key:   types.StringValue(keyString),
-			Value: types.StringValue(valueString),
+// mapEnvMapToMapValue converts an env map to a state value. Empty maps are
+// stored as null unless the prior value was an empty map as well.
+func mapEnvMapToMapValue(envMap map[string]string, prior types.Map) types.Map {
+	if len(envMap) == 0 {
+		if !prior.IsNull() && !prior.IsUnknown() && len(prior.Elements()) == 0 {
+			return prior
 		}
+		return types.MapNull(types.StringType)
+	}
 
-		envList = append(envList, newEnv)
+	values := make(map[string]attr.Value, len(envMap))
+	for key, value := range envMap {
+		values[key] = types.StringValue(value)
 	}
+	return types.MapValueMust(types.StringType, values)
+}
 
-	if len(envList) == 0 {
-		return nil
+func mapMapValueToEnvMap(ctx context.Context, value types.Map) (map[string]string, diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return nil, nil
 	}
 
-	return envList
+	envMap := make(map[string]string, len(value.Elements()))
+	diags := value.ElementsAs(ctx, &envMap, false)
+	return envMap, diags
+}
+
+// getInstanceEnvMaps returns the plain and secret envs of an instance plan.
+// Envs loaded from env_file are merged into the plain envs, with env taking
+// precedence. Write-only secret envs are read from the configuration and
+// merged into the secret envs.
+func getInstanceEnvMaps(ctx context.Context, plan InstanceResourceModel, config tfsdk.Config) (map[string]string, map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	envs, d := mapMapValueToEnvMap(ctx, plan.Env)
+	diags.Append(d...)
+
+	secretEnvs, d := mapMapValueToEnvMap(ctx, plan.EnvSecret)
+	diags.Append(d...)
+
+	writeOnlyEnvs, d := getWriteOnlyEnvMap(ctx, config, path.Root("env_secret_wo"))
+	diags.Append(d...)
+
+	envFileValues, d := mapMapValueToEnvMap(ctx, plan.EnvFileValues)
+	diags.Append(d...)
+
+	if len(envFileValues) > 0 {
+		merged := make(map[string]string, len(envFileValues)+len(envs))
+		maps.Copy(merged, envFileValues)
+		maps.Copy(merged, envs)
+		envs = merged
+	}
+
+	if len(writeOnlyEnvs) > 0 {
+		merged := make(map[string]string, len(secretEnvs)+len(writeOnlyEnvs))
+		maps.Copy(merged, secretEnvs)
+		maps.Copy(merged, writeOnlyEnvs)
+		secretEnvs = merged
+	}
+
+	return envs, secretEnvs, diags
+}
+
+// loadEnvFileValues reads and parses the env file of an instance. Keys which
+// are also defined as secret envs are rejected, as a variable can't be both
+// plain and secret.
+func loadEnvFileValues(ctx context.Context, envFile types.String, config tfsdk.Config) (types.Map, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if envFile.IsUnknown() {
+		return types.MapUnknown(types.StringType), diags
+	}
+	if envFile.IsNull() {
+		return types.MapNull(types.StringType), diags
+	}
+
+	content, err := os.ReadFile(envFile.ValueString())
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("env_file"),
+			"Unable to read env file.",
+			err.Error(),
+		)
+		return types.MapNull(types.StringType), diags
+	}
+
+	envFileValues, err := parseDotenv(string(content))
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("env_file"),
+			"Unable to parse env file.",
+			fmt.Sprintf("%s: %s", envFile.ValueString(), err.Error()),
+		)
+		return types.MapNull(types.StringType), diags
+	}
+
+	for _, name := range []string{"env_secret", "env_secret_wo"} {
+		var secretEnvs types.Map
+		diags.Append(config.GetAttribute(ctx, path.Root(name), &secretEnvs)...)
+		if diags.HasError() {
+			return types.MapNull(types.StringType), diags
+		}
+
+		for key := range secretEnvs.Elements() {
+			if _, ok := envFileValues[key]; ok {
+				diags.AddAttributeError(
+					path.Root(name).AtMapKey(key),
+					"Duplicate environment variable.",
+					fmt.Sprintf("Environment variable %s is defined in both env_file and %s.", key, name),
+				)
+			}
+		}
+	}
+
+	values := make(map[string]attr.Value, len(envFileValues))
+	for key, value := range envFileValues {
+		values[key] = types.StringValue(value)
+	}
+	return types.MapValueMust(types.StringType, values), diags
+}
+
+// splitEnvFileValues splits plain envs read from the API into envs managed by
+// the env attribute and envs loaded from env_file, based on the prior state.
+func splitEnvFileValues(ctx context.Context, readEnvs map[string]string, priorEnv types.Map, priorEnvFileValues types.Map) (map[string]string, types.Map, diag.Diagnostics) {
+	if priorEnvFileValues.IsNull() || priorEnvFileValues.IsUnknown() {
+		return readEnvs, types.MapNull(types.StringType), nil
+	}
+
+	var diags diag.Diagnostics
+
+	priorEnvs, d := mapMapValueToEnvMap(ctx, priorEnv)
+	diags.Append(d...)
+
+	priorFileEnvs, d := mapMapValueToEnvMap(ctx, priorEnvFileValues)
+	diags.Append(d...)
+
+	envs := make(map[string]string, len(readEnvs))
+	fileValues := make(map[string]attr.Value, len(priorFileEnvs))
+	for key, value := range readEnvs {
+		fileValue, inFile := priorFileEnvs[key]
+		_, inEnv := priorEnvs[key]
+
+		if inFile && !inEnv {
+			fileValues[key] = types.StringValue(value)
+			continue
+		}
+
+		// Envs defined in both take their value from env, the overridden file
+		// value is kept as is.
+		if inFile {
+			fileValues[key] = types.StringValue(fileValue)
+		}
+		envs[key] = value
+	}
+
+	return envs, types.MapValueMust(types.StringType, fileValues), diags
+}
+
+var envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func envKeyValidator() validator.String {
+	return stringvalidator.RegexMatches(
+		envKeyRegex,
+		"must start with a letter or underscore and contain only letters, digits and underscores",
+	)
+}
+
+// validateUniqueEnvKeys reports env keys which are defined in more than one of
+// the given env map attributes.
+func validateUniqueEnvKeys(envMaps map[string]types.Map) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	names := make([]string, 0, len(envMaps))
+	for name := range envMaps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	definedIn := map[string]string{}
+	for _, name := range names {
+		envMap := envMaps[name]
+		if envMap.IsNull() || envMap.IsUnknown() {
+			continue
+		}
+
+		keys := make([]string, 0, len(envMap.Elements()))
+		for key := range envMap.Elements() {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if other, ok := definedIn[key]; ok {
+				diags.AddAttributeError(
+					path.Root(name).AtMapKey(key),
+					"Duplicate environment variable.",
+					fmt.Sprintf("Environment variable %s is defined in both %s and %s.", key, other, name),
+				)
+				continue
+			}
+			definedIn[key] = name
+		}
+	}
+
+	return diags
 }
 
 func ParseClientPorts(responseString string) ([]client.Port, error) {
-	trimmedString := strings.TrimPrefix(responseString, "data: ")
+	trimmedString := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(responseString), "data:"))
 
 	type ResponseData struct {
 		Type int `json:"type"`
@@ -234,13 +615,23 @@ func isValidDomainType(value string) bool {
 }
 
 func getPortFromDeploymentURL(input client.InstanceOrder, urlStr string) (int, error) {
-	if (input.ProtocolData != nil && input.ProtocolData.ProviderHost != "") || input.URLPreview != "" {
+	if input.ClusterInstanceConfiguration == nil {
+		return 0, fmt.Errorf("no matching port found for the provided URL")
+	}
+
+	providerHost := getOrderProviderHost(input)
+
+	if providerHost != "" || input.URLPreview != "" {
 		for _, port := range input.ClusterInstanceConfiguration.Ports {
 			if urlStr == input.URLPreview && port.ExposedPort == 80 {
 				return port.ContainerPort, nil
 			}
 
-			expectedURL := fmt.Sprintf("%s:%d", input.ProtocolData.ProviderHost, port.ExposedPort)
+			if providerHost == "" {
+				continue
+			}
+
+			expectedURL := fmt.Sprintf("%s:%d", providerHost, port.ExposedPort)
 			if urlStr == expectedURL {
 				return port.ContainerPort, nil
 			}
@@ -259,15 +650,96 @@ func findDomainByID(domains []client.Domain, id string) (client.Domain, error) {
 	return client.Domain{}, fmt.Errorf("Domain with ID %s not found", id)
 }
 
+func findDomainByName(domains []client.Domain, name string) (client.Domain, error) {
+	for _, domain := range domains {
+		if domain.Name == name {
+			return domain, nil
+		}
+	}
+	return client.Domain{}, fmt.Errorf("Domain with name %s not found", name)
+}
+
+func findClusterByName(clusters []client.Cluster, name string) (client.Cluster, error) {
+	for _, cluster := range clusters {
+		if cluster.Name == name {
+			return cluster, nil
+		}
+	}
+	return client.Cluster{}, fmt.Errorf("Cluster with name %s not found", name)
+}
+
+func findInstanceByName(instances []client.Instance, name string) (client.Instance, error) {
+	for _, instance := range instances {
+		if instance.Name == name {
+			return instance, nil
+		}
+	}
+	return client.Instance{}, fmt.Errorf("Instance with name %s not found", name)
+}
+
+func findInstanceIDByName(ctx context.Context, api *client.SpheronApi, clusterName string, instanceName string) (string, error) {
+	organizationID, err := api.GetOrganizationId(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	clusters, err := api.GetOrganizationClusters(ctx, organizationID)
+	if err != nil {
+		return "", err
+	}
+
+	cluster, err := findClusterByName(clusters, clusterName)
+	if err != nil {
+		return "", err
+	}
+
+	instances, err := api.GetClusterInstances(ctx, cluster.ID)
+	if err != nil {
+		return "", err
+	}
+
+	instance, err := findInstanceByName(instances, instanceName)
+	if err != nil {
+		return "", err
+	}
+
+	return instance.ID, nil
+}
+
+// resolveInstanceImportID accepts either an instance id or a
+// <cluster_name>/<instance_name> pair and returns the instance id.
+func resolveInstanceImportID(ctx context.Context, api *client.SpheronApi, importID string) (string, error) {
+	split := strings.SplitN(importID, "/", 2)
+	if len(split) != 2 {
+		return importID, nil
+	}
+
+	if split[0] == "" || split[1] == "" {
+		return "", fmt.Errorf("Expected import identifier with format: <cluster_name>/<instance_name> or <instance_id>. Got: %s", importID)
+	}
+
+	return findInstanceIDByName(ctx, api, split[0], split[1])
+}
+
 func getInstanceDeploymentURL(input client.InstanceOrder, desiredPort int) string {
-	if (input.ProtocolData != nil && input.ProtocolData.ProviderHost != "") || input.URLPreview != "" {
+	if input.ClusterInstanceConfiguration == nil {
+		return ""
+	}
+
+	providerHost := getOrderProviderHost(input)
+
+	if providerHost != "" || input.URLPreview != "" {
 		for _, port := range input.ClusterInstanceConfiguration.Ports {
 			if port.ContainerPort == desiredPort {
 				if port.ExposedPort == 80 && input.URLPreview != "" {
 					return input.URLPreview
 				}
 
-				return fmt.Sprintf("%s:%d", input.ProtocolData.ProviderHost, port.ExposedPort)
+				if providerHost == "" {
+					return ""
+				}
+
+				return fmt.Sprintf("%s:%d", providerHost, port.ExposedPort)
 			}
 		}
 	}
@@ -275,6 +747,29 @@ func getInstanceDeploymentURL(input client.InstanceOrder, desiredPort int) strin
 	return ""
 }
 
+// checkOrderConfiguration fails for orders returned without a configuration,
+// so callers can read the deployed configuration without nil checks.
+func checkOrderConfiguration(order client.InstanceOrder) error {
+	if order.ClusterInstanceConfiguration == nil {
+		return fmt.Errorf("Instance order %s has no deployment configuration.", order.ID)
+	}
+	return nil
+}
+
+func getOrderProviderHost(input client.InstanceOrder) string {
+	if input.ProtocolData == nil {
+		return ""
+	}
+	return input.ProtocolData.ProviderHost
+}
+
+func formatInstanceCreatedAt(instance client.Instance) string {
+	if instance.CreatedAt.IsZero() {
+		return ""
+	}
+	return instance.CreatedAt.Format(time.RFC3339)
+}
+
 var persistentStorageClassMap = map[string]string{
 	"HDD":  "beta1",
 	"SSD":  "beta2",
@@ -284,7 +779,7 @@ var persistentStorageClassMap = map[string]string{
 func GetPersistentStorageClassEnum(key string) (string, error) {
 	value, ok := persistentStorageClassMap[key]
 	if !ok {
-		return "", fmt.Errorf("Storage class: %s is not supported. Supported values are: HDD, SSD and NVMe.", value)
+		return "", fmt.Errorf("Storage class: %s is not supported. Supported values are: HDD, SSD and NVMe.", key)
 	}
 	return value, nil
 }
@@ -302,9 +797,214 @@ func GetStorageClassFromValue(value string) (string, error) {
 	return class, nil
 }
 
+func getHealthCheckAtrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"port": types.Int64Type,
+		"path": types.StringType,
+	}
+}
+
+func mapClientHealthCheckToObject(healthCheck client.HealthCheck) types.Object {
+	if healthCheck.Port == (client.Port{}) {
+		return types.ObjectNull(getHealthCheckAtrTypes())
+	}
+
+	return types.ObjectValueMust(getHealthCheckAtrTypes(), map[string]attr.Value{
+		"port": types.Int64Value(int64(healthCheck.Port.ContainerPort)),
+		"path": types.StringValue(healthCheck.URL),
+	})
+}
+
+func getPersistentStorageAtrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"class":       types.StringType,
+		"mount_point": types.StringType,
+		"size":        types.Int64Type,
+	}
+}
+
+func mapClientPersistentStorageToObject(persistentStorage *client.PersistentStorage) types.Object {
+	if persistentStorage == nil || persistentStorage.Class == "" {
+		return types.ObjectNull(getPersistentStorageAtrTypes())
+	}
+
+	// Classes unknown to the provider are kept as returned by the API, so they
+	// show up as a diff instead of an empty class.
+	class, err := GetStorageClassFromValue(persistentStorage.Class)
+	if err != nil {
+		class = persistentStorage.Class
+	}
+
+	return types.ObjectValueMust(getPersistentStorageAtrTypes(), map[string]attr.Value{
+		"class":       types.StringValue(class),
+		"mount_point": types.StringValue(persistentStorage.MountPoint),
+		"size":        types.Int64Value(parseGiSize(persistentStorage.Size)),
+	})
+}
+
+// parseGiSize converts sizes like "10Gi" to the number of GB, returning 0 for
+// malformed values.
+func parseGiSize(value string) int64 {
+	number, _ := strconv.Atoi(RemoveGiSuffix(value))
+	return int64(number)
+}
+
+// normalizeStringList maps empty lists returned by the API to null so they
+// match omitted configuration.
+func normalizeStringList(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
 func RemoveGiSuffix(input string) string {
-	if len(input) < 2 {
-		return input
+	return strings.TrimSuffix(input, "Gi")
+}
+
+// anyRegion is the region value which lets Spheron pick any available region.
+const anyRegion = "any"
+
+// getPlannedChange returns the planned value of an attribute and whether it
+// differs from the prior state. Attributes of resources being created are
+// always reported as changed.
+func getPlannedChange[T attr.Value](ctx context.Context, plan tfsdk.Plan, state tfsdk.State, attributePath path.Path) (T, bool, diag.Diagnostics) {
+	var planned, prior T
+
+	diags := plan.GetAttribute(ctx, attributePath, &planned)
+	if diags.HasError() || state.Raw.IsNull() {
+		return planned, true, diags
+	}
+
+	diags.Append(state.GetAttribute(ctx, attributePath, &prior)...)
+	return planned, !planned.Equal(prior), diags
+}
+
+// validateMachineImagePlan checks a planned machine image against the compute
+// machine catalog, together with cpu and memory set next to it. Catalog errors
+// are reported as warnings so planning doesn't depend on the catalog API.
+func validateMachineImagePlan(ctx context.Context, api *client.SpheronApi, machineImage, cpu, memory types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if machineImage.IsUnknown() || isCustomMachineImage(machineImage.ValueString()) {
+		return diags
+	}
+
+	machines, err := api.GetComputeMachines(ctx)
+	if err != nil {
+		diags.AddAttributeWarning(
+			path.Root("machine_image"),
+			"Unable to validate machine image.",
+			err.Error(),
+		)
+		return diags
+	}
+
+	machine, err := findComputeMachine(machines, machineImage.ValueString())
+	if err != nil {
+		names := make([]string, 0, len(machines))
+		for _, machine := range machines {
+			names = append(names, machine.Name)
+		}
+
+		diags.AddAttributeError(
+			path.Root("machine_image"),
+			"Unknown machine image.",
+			fmt.Sprintf("Machine image %s doesn't exist. Available machine images: %s, or %s for custom cpu and memory.", machineImage.ValueString(), strings.Join(names, ", "), customMachineImage),
+		)
+		return diags
+	}
+
+	if cpu.IsUnknown() || memory.IsUnknown() {
+		return diags
+	}
+
+	if err := checkComputeMachineSpecs(machine, cpu.ValueString(), memory.ValueString()); err != nil {
+		diags.AddAttributeError(
+			path.Root("machine_image"),
+			"Machine image doesn't match provided cpu and memory.",
+			err.Error(),
+		)
+	}
+
+	return diags
+}
+
+// validateRegionPlan checks a planned region against the regions compute
+// machines are available in. Regions are not validated when the API doesn't
+// serve the regions list.
+func validateRegionPlan(ctx context.Context, api *client.SpheronApi, region types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if region.IsUnknown() || region.IsNull() || region.ValueString() == anyRegion {
+		return diags
+	}
+
+	regions, err := api.GetComputeMachineRegions(ctx)
+	if errors.Is(err, client.ErrNotFound) {
+		tflog.Warn(ctx, "Regions list not available, skipping region validation.", map[string]any{"error": err.Error()})
+		return diags
+	}
+	if err != nil {
+		diags.AddAttributeWarning(
+			path.Root("region"),
+			"Unable to validate region.",
+			err.Error(),
+		)
+		return diags
 	}
-	return input[:len(input)-2]
+
+	for _, available := range regions {
+		if available == region.ValueString() {
+			return diags
+		}
+	}
+
+	diags.AddAttributeError(
+		path.Root("region"),
+		"Unknown region.",
+		fmt.Sprintf("Region %s doesn't exist. Available regions: %s, or %s to deploy to any of them.", region.ValueString(), strings.Join(regions, ", "), anyRegion),
+	)
+	return diags
+}
+
+// validateMarketplaceVariablesPlan checks that all variables of a marketplace
+// app are set and warns about variables the app doesn't define, as those are
+// not passed to the deployment.
+func validateMarketplaceVariablesPlan(app client.MarketplaceApp, envKeys []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	keys := make(map[string]bool, len(envKeys))
+	for _, key := range envKeys {
+		keys[key] = true
+	}
+
+	defined := make(map[string]bool, len(app.ServiceData.Variables))
+	missing := []string{}
+	for _, appVar := range app.ServiceData.Variables {
+		defined[appVar.Name] = true
+		if !keys[appVar.Name] {
+			missing = append(missing, appVar.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		diags.AddAttributeError(
+			path.Root("env"),
+			"Missing required marketplace variables.",
+			fmt.Sprintf("Marketplace app %s requires variables: %s. Set them in env or env_wo.", app.Name, strings.Join(missing, ", ")),
+		)
+	}
+
+	for _, key := range envKeys {
+		if !defined[key] {
+			diags.AddAttributeWarning(
+				path.Root("env"),
+				"Unknown marketplace variable.",
+				fmt.Sprintf("Marketplace app %s doesn't define variable %s, it won't be passed to the instance.", app.Name, key),
+			)
+		}
+	}
+
+	return diags
 }
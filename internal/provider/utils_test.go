@@ -0,0 +1,641 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"terraform-provider-spherontest/internal/client"
+	"terraform-provider-spherontest/internal/fakeapi"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testOrder returns an order deploying ports on providerHost with urlPreview.
+func testOrder(providerHost string, urlPreview string, ports ...client.Port) client.InstanceOrder {
+	order := client.InstanceOrder{
+		URLPreview:                   urlPreview,
+		ClusterInstanceConfiguration: &client.ClusterInstanceConfiguration{Ports: ports},
+	}
+	if providerHost != "" {
+		order.ProtocolData = &client.ProtocolData{ProviderHost: providerHost}
+	}
+	return order
+}
+
+func TestParseClientPorts(t *testing.T) {
+	testCases := map[string]struct {
+		event       string
+		expected    []client.Port
+		expectError bool
+	}{
+		"event-data": {
+			event:    `data: {"type":2,"data":{"deploymentStatus":"DEPLOYED","ports":[{"containerPort":80,"exposedPort":30000},{"containerPort":443,"exposedPort":30001}]}}` + "\n",
+			expected: []client.Port{{ContainerPort: 80, ExposedPort: 30000}, {ContainerPort: 443, ExposedPort: 30001}},
+		},
+		"without-data-prefix": {
+			event:    ` {"type":2,"data":{"ports":[{"containerPort":80,"exposedPort":80}]}} `,
+			expected: []client.Port{{ContainerPort: 80, ExposedPort: 80}},
+		},
+		"no-ports": {
+			event:    `data: {"type":2,"data":{"deploymentStatus":"DEPLOYED"}}`,
+			expected: nil,
+		},
+		"invalid-json": {
+			event:       "data: deployed",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ports, err := ParseClientPorts(testCase.event)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", ports)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !slices.Equal(ports, testCase.expected) {
+				t.Errorf("expected %+v, got %+v", testCase.expected, ports)
+			}
+		})
+	}
+}
+
+func FuzzParseClientPorts(f *testing.F) {
+	f.Add(`data: {"type":2,"data":{"deploymentStatus":"DEPLOYED","ports":[{"containerPort":80,"exposedPort":30000},{"containerPort":443,"exposedPort":30001}]}}` + "\n")
+	f.Add(` {"type":2,"data":{"ports":[{"containerPort":80,"exposedPort":80}]}} `)
+	f.Add(`data: {"type":2,"data":{"deploymentStatus":"DEPLOYED"}}`)
+	f.Add(`data: {"data":{"ports":null}}`)
+	f.Add("data: deployed")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, event string) {
+		ports, err := ParseClientPorts(event)
+		if err != nil {
+			return
+		}
+
+		// The ports parsed from an event are parsed back from the event they
+		// are encoded in.
+		data, err := json.Marshal(map[string]any{"data": map[string]any{"ports": ports}})
+		if err != nil {
+			t.Fatalf("unexpected error encoding %+v: %s", ports, err)
+		}
+
+		reparsed, err := ParseClientPorts("data: " + string(data) + "\n")
+		if err != nil {
+			t.Fatalf("unexpected error parsing %s: %s", data, err)
+		}
+		if !slices.Equal(reparsed, ports) {
+			t.Errorf("expected %+v, got %+v", ports, reparsed)
+		}
+	})
+}
+
+func TestGetInstanceDeploymentURL(t *testing.T) {
+	testCases := map[string]struct {
+		order    client.InstanceOrder
+		port     int
+		expected string
+	}{
+		"provider-host": {
+			order:    testOrder("provider.example", "", client.Port{ContainerPort: 8080, ExposedPort: 31000}),
+			port:     8080,
+			expected: "provider.example:31000",
+		},
+		"exposed-on-80-uses-url-preview": {
+			order:    testOrder("provider.example", "https://preview.example", client.Port{ContainerPort: 3000, ExposedPort: 80}),
+			port:     3000,
+			expected: "https://preview.example",
+		},
+		"url-preview-only": {
+			order:    testOrder("", "https://preview.example", client.Port{ContainerPort: 3000, ExposedPort: 80}),
+			port:     3000,
+			expected: "https://preview.example",
+		},
+		"url-preview-only-other-port": {
+			order:    testOrder("", "https://preview.example", client.Port{ContainerPort: 3000, ExposedPort: 31000}),
+			port:     3000,
+			expected: "",
+		},
+		"port-not-deployed": {
+			order:    testOrder("provider.example", "", client.Port{ContainerPort: 8080, ExposedPort: 31000}),
+			port:     443,
+			expected: "",
+		},
+		"no-host": {
+			order:    testOrder("", "", client.Port{ContainerPort: 8080, ExposedPort: 31000}),
+			port:     8080,
+			expected: "",
+		},
+		"no-configuration": {
+			order:    client.InstanceOrder{URLPreview: "https://preview.example"},
+			port:     8080,
+			expected: "",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if actual := getInstanceDeploymentURL(testCase.order, testCase.port); actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetPortFromDeploymentURL(t *testing.T) {
+	ports := []client.Port{{ContainerPort: 3000, ExposedPort: 80}, {ContainerPort: 8080, ExposedPort: 31000}}
+
+	testCases := map[string]struct {
+		order       client.InstanceOrder
+		url         string
+		expected    int
+		expectError bool
+	}{
+		"provider-host": {
+			order:    testOrder("provider.example", "https://preview.example", ports...),
+			url:      "provider.example:31000",
+			expected: 8080,
+		},
+		"url-preview": {
+			order:    testOrder("provider.example", "https://preview.example", ports...),
+			url:      "https://preview.example",
+			expected: 3000,
+		},
+		"url-preview-only": {
+			order:    testOrder("", "https://preview.example", ports...),
+			url:      "https://preview.example",
+			expected: 3000,
+		},
+		"unknown-url": {
+			order:       testOrder("provider.example", "https://preview.example", ports...),
+			url:         "provider.example:32000",
+			expectError: true,
+		},
+		"no-configuration": {
+			order:       client.InstanceOrder{URLPreview: "https://preview.example"},
+			url:         "https://preview.example",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			port, err := getPortFromDeploymentURL(testCase.order, testCase.url)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got port %d", port)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if port != testCase.expected {
+				t.Errorf("expected port %d, got %d", testCase.expected, port)
+			}
+		})
+	}
+}
+
+func TestDeploymentURLRoundTrip(t *testing.T) {
+	order := testOrder("provider.example", "https://preview.example",
+		client.Port{ContainerPort: 3000, ExposedPort: 80},
+		client.Port{ContainerPort: 8080, ExposedPort: 31000},
+	)
+
+	for _, port := range []int{3000, 8080} {
+		url := getInstanceDeploymentURL(order, port)
+
+		actual, err := getPortFromDeploymentURL(order, url)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %s", url, err)
+		}
+		if actual != port {
+			t.Errorf("expected %s to map back to port %d, got %d", url, port, actual)
+		}
+	}
+}
+
+func FuzzGetPortFromDeploymentURL(f *testing.F) {
+	f.Add("provider.example", "https://preview.example", 3000, 80, "https://preview.example")
+	f.Add("provider.example", "https://preview.example", 8080, 31000, "provider.example:31000")
+	f.Add("provider.example", "", 80, 80, "provider.example:80")
+	f.Add("", "https://preview.example", 8080, 31000, "")
+	f.Add("", "", 0, 0, "")
+
+	f.Fuzz(func(t *testing.T, providerHost string, urlPreview string, containerPort int, exposedPort int, url string) {
+		order := testOrder(providerHost, urlPreview, client.Port{ContainerPort: containerPort, ExposedPort: exposedPort})
+
+		// Any URL either maps to the only port deployed or fails.
+		if port, err := getPortFromDeploymentURL(order, url); err == nil && port != containerPort {
+			t.Errorf("expected %q to map to port %d, got %d", url, containerPort, port)
+		}
+
+		// The URL of a port maps back to it.
+		deploymentURL := getInstanceDeploymentURL(order, containerPort)
+		if deploymentURL == "" {
+			return
+		}
+
+		port, err := getPortFromDeploymentURL(order, deploymentURL)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", deploymentURL, err)
+		}
+		if port != containerPort {
+			t.Errorf("expected %q to map back to port %d, got %d", deploymentURL, containerPort, port)
+		}
+	})
+}
+
+func TestSplitEnvValue(t *testing.T) {
+	testCases := map[string]struct {
+		value         string
+		expectedKey   string
+		expectedValue string
+		expectedOk    bool
+	}{
+		"key-value":        {value: "A=1", expectedKey: "A", expectedValue: "1", expectedOk: true},
+		"value-with-equal": {value: "URL=postgres://host/db?sslmode=disable", expectedKey: "URL", expectedValue: "postgres://host/db?sslmode=disable", expectedOk: true},
+		"empty-value":      {value: "A=", expectedKey: "A", expectedValue: "", expectedOk: true},
+		"no-equal":         {value: "A", expectedKey: "A", expectedValue: "", expectedOk: true},
+		"empty-key":        {value: "=1", expectedOk: false},
+		"empty":            {value: "", expectedOk: false},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			key, value, ok := splitEnvValue(testCase.value)
+			if ok != testCase.expectedOk || key != testCase.expectedKey || value != testCase.expectedValue {
+				t.Errorf("expected (%q, %q, %t), got (%q, %q, %t)", testCase.expectedKey, testCase.expectedValue, testCase.expectedOk, key, value, ok)
+			}
+		})
+	}
+}
+
+func TestMapClientEnvsToEnvMap(t *testing.T) {
+	envs := []client.Env{
+		{Value: "A=1"},
+		{Value: "B=x=y"},
+		{Value: "EMPTY="},
+		{Value: "=ignored"},
+		{Value: "S=secret", IsSecret: true},
+		{Value: "T=a=b", IsSecret: true},
+	}
+
+	testCases := map[string]struct {
+		isSecret bool
+		expected map[string]string
+	}{
+		"plain": {
+			isSecret: false,
+			expected: map[string]string{"A": "1", "B": "x=y", "EMPTY": ""},
+		},
+		"secret": {
+			isSecret: true,
+			expected: map[string]string{"S": "secret", "T": "a=b"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual := mapClientEnvsToEnvMap(envs, testCase.isSecret)
+			if !maps.Equal(actual, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMapEnvMapToClientEnvs(t *testing.T) {
+	envMap := map[string]string{"B": "x=y", "A": "", "C": "3"}
+	expected := []client.Env{
+		{Value: "A=", IsSecret: true},
+		{Value: "B=x=y", IsSecret: true},
+		{Value: "C=3", IsSecret: true},
+	}
+
+	actual := mapEnvMapToClientEnvs(envMap, true)
+	if !slices.Equal(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+
+	if roundTrip := mapClientEnvsToEnvMap(actual, true); !maps.Equal(roundTrip, envMap) {
+		t.Errorf("expected envs to map back to %v, got %v", envMap, roundTrip)
+	}
+}
+
+func FuzzSplitEnvValue(f *testing.F) {
+	for _, value := range []string{"A=1", "URL=postgres://host/db?sslmode=disable", "A=", "A", "=1", ""} {
+		f.Add(value)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		key, envValue, ok := splitEnvValue(value)
+		if !ok {
+			if key != "" || envValue != "" {
+				t.Errorf("expected %q to be rejected without a key or value, got (%q, %q)", value, key, envValue)
+			}
+			if value != "" && !strings.HasPrefix(value, "=") {
+				t.Errorf("expected %q to be split", value)
+			}
+			return
+		}
+
+		if key == "" || strings.Contains(key, "=") {
+			t.Errorf("expected a non empty key without =, got %q", key)
+		}
+
+		joined := key + "=" + envValue
+		if joined != value && (envValue != "" || key != value) {
+			t.Errorf("expected (%q, %q) to join back to %q", key, envValue, value)
+		}
+	})
+}
+
+func FuzzEnvMapRoundTrip(f *testing.F) {
+	f.Add("A", "1", "2", true)
+	f.Add("URL", "postgres://host/db?sslmode=disable", "", false)
+	f.Add("S", "****", "secret", true)
+	f.Add("EMPTY", "", "prior", true)
+	f.Add("", "1", "", false)
+	f.Add("A=B", "1", "", false)
+
+	f.Fuzz(func(t *testing.T, key string, value string, priorValue string, isSecret bool) {
+		envMap := map[string]string{key: value}
+		envs := mapEnvMapToClientEnvs(envMap, isSecret)
+
+		if other := mapClientEnvsToEnvMap(envs, !isSecret); len(other) != 0 {
+			t.Errorf("expected envs with another secrecy to be skipped, got %v", other)
+		}
+
+		roundTrip := mapClientEnvsToEnvMap(envs, isSecret)
+		if key != "" && !strings.Contains(key, "=") && !maps.Equal(roundTrip, envMap) {
+			t.Errorf("expected envs to map back to %v, got %v", envMap, roundTrip)
+		}
+
+		// Merging envs read back with the prior state only restores masked
+		// values.
+		merged := mergeSecretEnvs(roundTrip, map[string]string{key: priorValue}, false)
+		for mergedKey, mergedValue := range merged {
+			expected := roundTrip[mergedKey]
+			if mergedKey == key && isMaskedSecretValue(expected) {
+				expected = priorValue
+			}
+			if mergedValue != expected {
+				t.Errorf("expected merged %s to be %q, got %q", mergedKey, expected, mergedValue)
+			}
+		}
+		if !maps.Equal(mergeSecretEnvs(roundTrip, nil, true), map[string]string{}) {
+			t.Errorf("expected envs not in the prior state to be dropped")
+		}
+	})
+}
+
+func TestIsMaskedSecretValue(t *testing.T) {
+	testCases := map[string]struct {
+		value    string
+		expected bool
+	}{
+		"masked":        {value: "****", expected: true},
+		"single-star":   {value: "*", expected: true},
+		"empty":         {value: "", expected: false},
+		"value":         {value: "secret", expected: false},
+		"partly-masked": {value: "se**et", expected: false},
+		"star-prefix":   {value: "**secret", expected: false},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if actual := isMaskedSecretValue(testCase.value); actual != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSecretEnvsEqual(t *testing.T) {
+	testCases := map[string]struct {
+		plan     map[string]string
+		deployed map[string]string
+		expected bool
+	}{
+		"equal":            {plan: map[string]string{"S": "1"}, deployed: map[string]string{"S": "1"}, expected: true},
+		"changed":          {plan: map[string]string{"S": "2"}, deployed: map[string]string{"S": "1"}, expected: false},
+		"masked":           {plan: map[string]string{"W": "1"}, deployed: map[string]string{"W": "****"}, expected: true},
+		"added":            {plan: map[string]string{"S": "1", "T": "2"}, deployed: map[string]string{"S": "1"}, expected: false},
+		"removed":          {plan: map[string]string{}, deployed: map[string]string{"S": "****"}, expected: false},
+		"both-empty-nil":   {plan: nil, deployed: map[string]string{}, expected: true},
+		"value-with-equal": {plan: map[string]string{"S": "a=b"}, deployed: map[string]string{"S": "a=b"}, expected: true},
+		"deployed-empty":   {plan: map[string]string{"S": "1"}, deployed: map[string]string{"S": ""}, expected: false},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if actual := secretEnvsEqual(testCase.plan, testCase.deployed); actual != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCheckRequiredDeploymentVariables(t *testing.T) {
+	appVariables := []client.MarketplaceAppVariable{
+		{Name: "POSTGRES_PASSWORD", Label: "Password", Required: true},
+		{Name: "POSTGRES_DB", Label: "Database", DefaultValue: "postgres"},
+	}
+
+	env := func(key string, value string) Env {
+		return Env{Key: types.StringValue(key), Value: types.StringValue(value)}
+	}
+
+	testCases := map[string]struct {
+		envs          []Env
+		expected      []client.MarketplaceDeploymentVariable
+		expectedError string
+	}{
+		"all-set": {
+			envs: []Env{env("POSTGRES_DB", "app"), env("POSTGRES_PASSWORD", "a=b")},
+			expected: []client.MarketplaceDeploymentVariable{
+				{Label: "Database", Value: "app"},
+				{Label: "Password", Value: "a=b"},
+			},
+		},
+		"unknown-variables-ignored": {
+			envs: []Env{env("POSTGRES_PASSWORD", "secret"), env("POSTGRES_DB", ""), env("OTHER", "1")},
+			expected: []client.MarketplaceDeploymentVariable{
+				{Label: "Password", Value: "secret"},
+				{Label: "Database", Value: ""},
+			},
+		},
+		"missing": {
+			envs:          []Env{env("OTHER", "1")},
+			expectedError: "Missing required deployment variables: POSTGRES_PASSWORD, POSTGRES_DB",
+		},
+		"none": {
+			envs:          nil,
+			expectedError: "Missing required deployment variables: POSTGRES_PASSWORD, POSTGRES_DB",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			variables, err := checkRequiredDeploymentVariables(appVariables, testCase.envs)
+
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("expected error %q, got %v", testCase.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !slices.Equal(variables, testCase.expected) {
+				t.Errorf("expected %+v, got %+v", testCase.expected, variables)
+			}
+		})
+	}
+}
+
+func TestRemoveGiSuffix(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"gi":            {input: "16Gi", expected: "16"},
+		"fraction":      {input: "0.5Gi", expected: "0.5"},
+		"no-suffix":     {input: "16", expected: "16"},
+		"other-suffix":  {input: "512Mi", expected: "512Mi"},
+		"only-trailing": {input: "GiGi", expected: "Gi"},
+		"empty":         {input: "", expected: ""},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if actual := RemoveGiSuffix(testCase.input); actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPersistentStorageClasses(t *testing.T) {
+	testCases := map[string]struct {
+		class       string
+		value       string
+		expectError bool
+	}{
+		"hdd":         {class: "HDD", value: "beta1"},
+		"ssd":         {class: "SSD", value: "beta2"},
+		"nvme":        {class: "NVMe", value: "beta3"},
+		"unsupported": {class: "Tape", expectError: true},
+		"wrong-case":  {class: "nvme", expectError: true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			value, err := GetPersistentStorageClassEnum(testCase.class)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got %q", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if value != testCase.value {
+				t.Errorf("expected %q, got %q", testCase.value, value)
+			}
+
+			class, err := GetStorageClassFromValue(value)
+			if err != nil {
+				t.Fatalf("unexpected error mapping %q back: %s", value, err)
+			}
+			if class != testCase.class {
+				t.Errorf("expected %q to map back to %q, got %q", value, testCase.class, class)
+			}
+		})
+	}
+
+	if _, err := GetStorageClassFromValue("beta9"); err == nil {
+		t.Error("expected an unknown storage class value to fail")
+	}
+}
+
+func TestValidateRegionPlan(t *testing.T) {
+	testCases := map[string]struct {
+		region          types.String
+		handler         func(api *fakeapi.Server) http.Handler
+		expectedError   bool
+		expectedWarning bool
+	}{
+		"available": {
+			region: types.StringValue("us-east"),
+		},
+		"any": {
+			region: types.StringValue(anyRegion),
+		},
+		"unknown": {
+			region:        types.StringValue("mars"),
+			expectedError: true,
+		},
+		"regions-not-found": {
+			region: types.StringValue("mars"),
+			handler: func(api *fakeapi.Server) http.Handler {
+				api.RemoveRoute("GET /v1/compute-machine-image/regions")
+				return api
+			},
+		},
+		"regions-failing": {
+			region: types.StringValue("mars"),
+			handler: func(*fakeapi.Server) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				})
+			},
+			expectedWarning: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fake := fakeapi.New("token")
+			var handler http.Handler = fake
+			if testCase.handler != nil {
+				handler = testCase.handler(fake)
+			}
+
+			server := httptest.NewServer(handler)
+			t.Cleanup(server.Close)
+
+			api, err := client.NewSpheronApi("token", server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error creating client: %s", err)
+			}
+
+			diags := validateRegionPlan(context.Background(), api, testCase.region)
+			if diags.HasError() != testCase.expectedError {
+				t.Errorf("expected error: %t, got: %v", testCase.expectedError, diags)
+			}
+			if hasWarning := diags.WarningsCount() > 0; hasWarning != testCase.expectedWarning {
+				t.Errorf("expected warning: %t, got: %v", testCase.expectedWarning, diags)
+			}
+		})
+	}
+}
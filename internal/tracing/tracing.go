@@ -0,0 +1,132 @@
+// Package tracing sets up optional OpenTelemetry tracing of provider
+// operations and Spheron API calls.
+//
+// Tracing is configured with the standard OTEL_* env variables and is only
+// enabled when an OTLP endpoint is set, through OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT. Spans are otherwise recorded by the no-op
+// tracer of the global tracer provider.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer spans are recorded with.
+const InstrumentationName = "terraform-provider-spherontest"
+
+// defaultServiceName is reported when OTEL_SERVICE_NAME isn't set.
+const defaultServiceName = "terraform-provider-spherontest"
+
+// Span attributes identifying Spheron objects.
+const (
+	AttrClusterID   = attribute.Key("spheron.cluster_id")
+	AttrInstanceID  = attribute.Key("spheron.instance_id")
+	AttrOrderID     = attribute.Key("spheron.order_id")
+	AttrDomainID    = attribute.Key("spheron.domain_id")
+	AttrTopicID     = attribute.Key("spheron.topic_id")
+	AttrRequestID   = attribute.Key("spheron.request_id")
+	AttrResource    = attribute.Key("terraform.resource_type")
+	AttrTFOperation = attribute.Key("terraform.operation")
+)
+
+// Setup installs a tracer provider exporting spans over OTLP when tracing is
+// enabled by the env. The returned function flushes pending spans and must be
+// called before the provider exits.
+func Setup(ctx context.Context, version string) (func(context.Context) error, error) {
+	if !enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %v", err)
+	}
+
+	tracerProvider, err := NewTracerProvider(ctx, version, sdktrace.WithBatcher(exporter))
+	if err != nil {
+		return nil, err
+	}
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tracerProvider.Shutdown, nil
+}
+
+// NewTracerProvider returns a tracer provider describing the provider as the
+// traced service. Spans are passed to the span processors given in options,
+// for example sdktrace.WithSyncer with an in-memory exporter in tests.
+func NewTracerProvider(ctx context.Context, version string, options ...sdktrace.TracerProviderOption) (*sdktrace.TracerProvider, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(defaultServiceName),
+			semconv.ServiceVersion(version),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %v", err)
+	}
+
+	options = append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, options...)
+	return sdktrace.NewTracerProvider(options...), nil
+}
+
+// Start starts a span with the tracer of the provider.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// RecordError marks a span as failed with the given error.
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// enabled reports whether the env configures an OTLP trace endpoint, following
+// the OTEL_SDK_DISABLED and OTEL_TRACES_EXPORTER conventions.
+func enabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+
+	if exporter := os.Getenv("OTEL_TRACES_EXPORTER"); exporter != "" && exporter != "otlp" {
+		return false
+	}
+
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// newExporter returns an OTLP exporter for the protocol set in the env,
+// defaulting to http/protobuf. Endpoints, headers and TLS settings are read
+// from the env by the exporters.
+func newExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+
+	switch protocol {
+	case "", "http/protobuf":
+		return otlptracehttp.New(ctx)
+	case "grpc":
+		return otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q, supported protocols are grpc and http/protobuf", protocol)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 
 	"terraform-provider-spherontest/internal/provider"
+	"terraform-provider-spherontest/internal/tracing"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 )
@@ -41,7 +42,18 @@ func main() {
 		Debug:   debug,
 	}
 
-	err := providerserver.Serve(context.Background(), provider.New(version), opts)
+	ctx := context.Background()
+
+	shutdownTracing, err := tracing.Setup(ctx, version)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	err = providerserver.Serve(ctx, provider.New(version), opts)
+
+	if shutdownErr := shutdownTracing(ctx); shutdownErr != nil {
+		log.Printf("failed to flush traces: %s", shutdownErr.Error())
+	}
 
 	if err != nil {
 		log.Fatal(err.Error())